@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+const (
+	EXIT_OK          = 0
+	EXIT_ERROR       = 1
+	EXIT_USAGE       = 2
+	EXIT_REPO_NOT_OK = 3
+	EXIT_NOT_A_REPO  = 4
+	// the server couldn't be reached or didn't accept the credentials
+	EXIT_REMOTE = 5
+)
+
+const USAGE = `Usage: ugsg [--repo <path>] [--json] <command> [arguments]
+
+--repo and --json go before or after the command.
+
+Commands:
+  status                          Show branch, ahead/behind, changes and locks
+  pull                            Fetch and pull (fast forward or rebase, whatever is needed)
+  sync                            Fetch, pull, then push
+  locks list [--mine]             List the LFS locks
+  locks unlock [--force] (--unchanged | <id>...)
+                                  Unlock by id, or every file you locked and didn't change
+  fix-config                      Create or link the project .gitconfig
+
+Without a command ugsg starts the GUI, ugsg-cli only has the commands.
+`
+
+type CLI struct {
+	Repo   *core.Repo
+	Json   bool
+	Stdout io.Writer
+	Stderr io.Writer
+	// Runs the git commands when set instead of the real git, for tests
+	Runner core.Runner
+
+	repoPath string
+}
+
+// Whether the arguments are meant for the command line, anything else (a file the OS opened us with, macOS' -psn_...) starts the GUI
+func IsCommandLine(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if !strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "status", "pull", "sync", "fix-config", "locks":
+			return true
+		}
+		return false
+	}
+	switch strings.TrimLeft(strings.SplitN(args[0], "=", 2)[0], "-") {
+	case "repo", "json", "h", "help":
+		return true
+	}
+	return false
+}
+
+// Returns the exit code
+func Run(args []string) int {
+	cli := &CLI{Stdout: os.Stdout, Stderr: os.Stderr}
+	return cli.Run(args)
+}
+
+// Returns the exit code
+func (cli *CLI) Run(args []string) int {
+	flags := cli.newFlagSet("ugsg")
+	err := flags.Parse(args)
+	if err != nil {
+		return EXIT_USAGE
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return EXIT_USAGE
+	}
+
+	// everything is parsed before touching the repo, --repo can come after the command
+	command, ok := cli.parseCommand(flags.Arg(0), flags.Args()[1:])
+	if !ok {
+		return EXIT_USAGE
+	}
+
+	if cli.repoPath == "" && core.FileExists(core.CONFIG_FILE) {
+		cli.Repo = core.OpenRepoFromConfig(core.LoadConfig(core.CONFIG_FILE))
+	} else {
+		if cli.repoPath == "" {
+			cli.repoPath = "."
+		}
+		cli.Repo = core.OpenRepo(cli.repoPath)
+	}
+	if cli.Runner != nil {
+		cli.Repo = cli.Repo.WithRunner(cli.Runner)
+	}
+
+	err = core.CheckRepo(cli.Repo)
+	if errors.Is(err, core.ErrNotARepo) {
+		return cli.fail(EXIT_NOT_A_REPO, fmt.Errorf("%s is not a git repository", cli.Repo.Path))
+	}
+	if err != nil {
+		return cli.fail(EXIT_ERROR, err)
+	}
+
+	return command()
+}
+
+// Every flag set takes the global flags too
+func (cli *CLI) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(cli.Stderr)
+	flags.Usage = func() { fmt.Fprint(cli.Stderr, USAGE) }
+	flags.StringVar(&cli.repoPath, "repo", cli.repoPath, "path to the repository (defaults to the ugsg.json project or the current directory)")
+	flags.BoolVar(&cli.Json, "json", cli.Json, "machine readable output")
+	return flags
+}
+
+// Parses the command line of a command and returns what runs it, false when it is wrong (the usage is printed already)
+func (cli *CLI) parseCommand(name string, args []string) (func() int, bool) {
+	switch name {
+	case "status":
+		return cli.status, cli.parseNoArgs(name, args)
+	case "pull":
+		return cli.pull, cli.parseNoArgs(name, args)
+	case "sync":
+		return cli.sync, cli.parseNoArgs(name, args)
+	case "fix-config":
+		return cli.fixConfig, cli.parseNoArgs(name, args)
+	case "locks":
+		return cli.parseLocks(args)
+	}
+
+	fmt.Fprintf(cli.Stderr, "Unknown command: %s\n\n", name)
+	fmt.Fprint(cli.Stderr, USAGE)
+	return nil, false
+}
+
+// For the commands that take nothing but the global flags
+func (cli *CLI) parseNoArgs(name string, args []string) bool {
+	flags := cli.newFlagSet(name)
+	err := flags.Parse(args)
+	if err != nil {
+		return false
+	}
+	return cli.noExtraArgs(flags)
+}
+
+func (cli *CLI) noExtraArgs(flags *flag.FlagSet) bool {
+	if flags.NArg() > 0 {
+		fmt.Fprintf(cli.Stderr, "Unexpected argument for %s: %s\n\n", flags.Name(), flags.Arg(0))
+		flags.Usage()
+		return false
+	}
+	return true
+}
+
+// Prints the result as json or as whatever printText writes
+func (cli *CLI) print(result any, printText func(io.Writer)) {
+	if cli.Json {
+		encoder := json.NewEncoder(cli.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+		return
+	}
+	printText(cli.Stdout)
+}
+
+type errorResult struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+	// only for errors we recognize, Error is the plain explanation then
+	Kind    string `json:"kind,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// EXIT_REMOTE for network and credential failures, so scripts can retry those
+func remoteExitCode(err error) int {
+	if errors.Is(err, core.ErrNetwork) || errors.Is(err, core.ErrAuth) {
+		return EXIT_REMOTE
+	}
+	return EXIT_ERROR
+}
+
+func (cli *CLI) fail(exitCode int, err error) int {
+	result := errorResult{Ok: false, Error: err.Error()}
+	var actionable *core.ActionableError
+	if errors.As(core.ClassifyError(err), &actionable) {
+		result.Error = actionable.Explanation
+		result.Kind = actionable.Kind.String()
+		result.Details = actionable.Err.Error()
+	}
+
+	if cli.Json {
+		cli.print(result, nil)
+	} else {
+		fmt.Fprintf(cli.Stderr, "error: %s\n", result.Error)
+		if result.Details != "" {
+			fmt.Fprintf(cli.Stderr, "\n%s\n", result.Details)
+		}
+	}
+	return exitCode
+}
+
+type okResult struct {
+	Ok      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+func (cli *CLI) succeed(message string) int {
+	cli.print(okResult{Ok: true, Message: message}, func(w io.Writer) {
+		fmt.Fprintln(w, message)
+	})
+	return EXIT_OK
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+// testdata/*.json were recorded running the commands with a RecordingRunner on a repo with an LFS server,
+// alice has Content/Hero.uasset locked and bob Content/Villain.uasset.
+
+type cliTest struct {
+	name string
+	// replayed by a FakeRunner, empty when no git should run
+	fixture  string
+	args     []string
+	exitCode int
+	// in stdout, or in the json output when it has --json
+	want   string
+	stderr string
+}
+
+// Runs the command on an empty folder answering with the recorded calls, failing if any of them is left over
+func runCLI(t *testing.T, test cliTest) (string, string) {
+	t.Helper()
+	fake := &core.FakeRunner{}
+	if test.fixture != "" {
+		var err error
+		fake, err = core.LoadFakeRunner(filepath.Join("testdata", test.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	cli := &CLI{Stdout: &stdout, Stderr: &stderr, Runner: fake}
+
+	exitCode := cli.Run(append([]string{"--repo", t.TempDir()}, test.args...))
+	if exitCode != test.exitCode {
+		t.Errorf("got exit code %d, want %d\nstdout: %s\nstderr: %s", exitCode, test.exitCode, stdout.String(), stderr.String())
+	}
+	for _, call := range fake.Unused() {
+		t.Errorf("recorded call never replayed: %q", call.Args)
+	}
+	return stdout.String(), stderr.String()
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []cliTest{
+		{name: "no command", exitCode: EXIT_USAGE, stderr: "Usage:"},
+		{name: "unknown command", args: []string{"frobnicate"}, exitCode: EXIT_USAGE, stderr: "Unknown command: frobnicate"},
+		{name: "extra argument", args: []string{"status", "now"}, exitCode: EXIT_USAGE, stderr: "Unexpected argument for status: now"},
+		{name: "extra argument to locks list", args: []string{"locks", "list", "everything"}, exitCode: EXIT_USAGE, stderr: "Unexpected argument for locks list"},
+		{name: "unknown flag", args: []string{"pull", "--rebase"}, exitCode: EXIT_USAGE},
+		{name: "unlock without ids", args: []string{"locks", "unlock"}, exitCode: EXIT_USAGE, stderr: "Usage:"},
+		{name: "unlock ids and unchanged", args: []string{"locks", "unlock", "--unchanged", "1"}, exitCode: EXIT_USAGE, stderr: "not both"},
+		{name: "status", fixture: "status.json", args: []string{"status"}, exitCode: EXIT_OK, want: "Locked:   1\n"},
+		{name: "status without user", fixture: "status_no_user.json", args: []string{"status"}, exitCode: EXIT_OK, want: "Locked:   unknown\n", stderr: "No git user.name set"},
+		{name: "pull on a detached head", fixture: "pull_detached.json", args: []string{"pull"}, exitCode: EXIT_REPO_NOT_OK, stderr: "detached-head"},
+		{name: "locks list", fixture: "locks_mine.json", args: []string{"locks", "list", "--mine"}, exitCode: EXIT_OK, want: "1\talice\tContent/Hero.uasset\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr := runCLI(t, test)
+			if !strings.Contains(stdout, test.want) {
+				t.Errorf("stdout %q doesn't have %q", stdout, test.want)
+			}
+			if !strings.Contains(stderr, test.stderr) {
+				t.Errorf("stderr %q doesn't have %q", stderr, test.stderr)
+			}
+		})
+	}
+}
+
+func TestIsCommandLine(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"status"}, true},
+		{[]string{"locks", "list"}, true},
+		{[]string{"--repo", "Game", "pull"}, true},
+		{[]string{"--repo=Game", "pull"}, true},
+		{[]string{"-json", "status"}, true},
+		{[]string{"--help"}, true},
+		{[]string{"-psn_0_12345"}, false},
+		{[]string{"Game/ugsg.json"}, false},
+		{[]string{"--status"}, false},
+	}
+	for _, test := range tests {
+		got := IsCommandLine(test.args)
+		if got != test.want {
+			t.Errorf("IsCommandLine(%q) = %v, want %v", test.args, got, test.want)
+		}
+	}
+}
+
+func TestNotARepo(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	cli := &CLI{Stdout: &stdout, Stderr: &stderr, Runner: &core.FakeRunner{}}
+	exitCode := cli.Run([]string{"status", "--json", "--repo", filepath.Join(t.TempDir(), "missing")})
+	if exitCode != EXIT_NOT_A_REPO {
+		t.Errorf("got exit code %d, want %d", exitCode, EXIT_NOT_A_REPO)
+	}
+	var result errorResult
+	err := json.Unmarshal(stdout.Bytes(), &result)
+	if err != nil {
+		t.Fatalf("not json: %v\n%s", err, stdout.String())
+	}
+	if result.Ok || !strings.Contains(result.Error, "not a git repository") {
+		t.Errorf("got %+v", result)
+	}
+}
+
+func TestStatusJson(t *testing.T) {
+	// --json before and after the command
+	for _, args := range [][]string{{"--json", "status"}, {"status", "--json"}} {
+		stdout, _ := runCLI(t, cliTest{fixture: "status.json", args: args, exitCode: EXIT_OK})
+		var result statusResult
+		err := json.Unmarshal([]byte(stdout), &result)
+		if err != nil {
+			t.Fatalf("%q: not json: %v\n%s", args, err, stdout)
+		}
+		if !result.Ok || result.Branch != "main" || result.Status != "ok" || result.User != "alice" {
+			t.Errorf("%q: got %+v", args, result)
+		}
+		// bob's lock isn't counted
+		if result.LockedByMe == nil || *result.LockedByMe != 1 {
+			t.Errorf("%q: got lockedByMe %v, want 1", args, result.LockedByMe)
+		}
+	}
+}
+
+func TestStatusJsonWithoutUser(t *testing.T) {
+	stdout, _ := runCLI(t, cliTest{fixture: "status_no_user.json", args: []string{"status", "--json"}, exitCode: EXIT_OK})
+	if strings.Contains(stdout, "lockedByMe") {
+		t.Errorf("lockedByMe without a user, every lock would count:\n%s", stdout)
+	}
+	var result statusResult
+	err := json.Unmarshal([]byte(stdout), &result)
+	if err != nil {
+		t.Fatalf("not json: %v\n%s", err, stdout)
+	}
+	if len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], "locks:") {
+		t.Errorf("got warnings %q", result.Warnings)
+	}
+}
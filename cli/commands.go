@@ -0,0 +1,324 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+var errNoUser = errors.New("No git user.name set, can't tell which locks are yours")
+
+type statusResult struct {
+	Ok      bool   `json:"ok"`
+	Repo    string `json:"repo"`
+	Branch  string `json:"branch"`
+	Status  string `json:"status"`
+	Ahead   int    `json:"ahead"`
+	Behind  int    `json:"behind"`
+	Changes int    `json:"changes"`
+	// missing when we don't know who you are
+	LockedByMe   *int   `json:"lockedByMe,omitempty"`
+	User         string `json:"user"`
+	Email        string `json:"email"`
+	ConfigStatus string `json:"configStatus"`
+	// parts that couldn't be read, the rest of the status is still right
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (cli *CLI) status() int {
+	repo := cli.Repo
+	snapshot, err := core.GetRepoSnapshot(repo, true)
+	if err != nil {
+		return cli.fail(EXIT_ERROR, err)
+	}
+	status, err := core.GetGitStatusFromSnapshot(repo, snapshot)
+	if err != nil {
+		return cli.fail(EXIT_ERROR, err)
+	}
+
+	result := statusResult{
+		Ok:       status == core.GIT_STATUS_OK,
+		Repo:     repo.Path,
+		Branch:   snapshot.Branch,
+		Status:   status.String(),
+		Ahead:    snapshot.Ahead,
+		Behind:   snapshot.Behind,
+		Changes:  snapshot.ChangeAmount(),
+		Warnings: make([]string, 0),
+	}
+	warn := func(what string, err error) {
+		result.Warnings = append(result.Warnings, what+": "+err.Error())
+	}
+
+	var userErr error
+	result.User, userErr = core.GetUsernameFromRepo(repo)
+	if userErr != nil {
+		warn("user", userErr)
+	}
+	result.Email, err = core.GetUserEmailFromRepo(repo)
+	if err != nil {
+		warn("email", err)
+	}
+	configStatus, err := core.GetGitConfigStatus(repo)
+	if err != nil {
+		warn("config", err)
+	}
+	result.ConfigStatus = configStatus.String()
+
+	if result.User != "" {
+		lockedFiles, err := core.GetLockedFiles(repo, result.User)
+		if err != nil {
+			warn("locks", err)
+		} else {
+			lockedByMe := len(lockedFiles)
+			result.LockedByMe = &lockedByMe
+		}
+	} else if userErr == nil {
+		// without a user every lock would look like ours
+		warn("locks", errNoUser)
+	}
+
+	cli.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "Repo:     %s\n", result.Repo)
+		fmt.Fprintf(w, "Branch:   %s\n", result.Branch)
+		fmt.Fprintf(w, "Status:   %s\n", result.Status)
+		fmt.Fprintf(w, "Ahead:    %d\n", result.Ahead)
+		fmt.Fprintf(w, "Behind:   %d\n", result.Behind)
+		fmt.Fprintf(w, "Changes:  %d\n", result.Changes)
+		if result.LockedByMe != nil {
+			fmt.Fprintf(w, "Locked:   %d\n", *result.LockedByMe)
+		} else {
+			fmt.Fprintf(w, "Locked:   unknown\n")
+		}
+		fmt.Fprintf(w, "User:     %s (%s)\n", result.User, result.Email)
+		fmt.Fprintf(w, "Config:   %s\n", result.ConfigStatus)
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(cli.Stderr, "warning: %s\n", warning)
+		}
+	})
+
+	if !result.Ok {
+		return EXIT_REPO_NOT_OK
+	}
+	return EXIT_OK
+}
+
+func (cli *CLI) checkRepoOk(action string) error {
+	status, err := core.GetGitStatus(cli.Repo)
+	if err != nil {
+		return err
+	}
+	if status != core.GIT_STATUS_OK {
+		return fmt.Errorf("Repo not ok (%s). Can't %s", status, action)
+	}
+	return nil
+}
+
+func (cli *CLI) pull() int {
+	err := cli.checkRepoOk("pull")
+	if err != nil {
+		return cli.fail(EXIT_REPO_NOT_OK, err)
+	}
+
+	// the pull only brings in what the last fetch saw
+	err = core.GitFetch(cli.Repo)
+	if err != nil {
+		return cli.fail(remoteExitCode(err), err)
+	}
+
+	err = core.GitSmartPull(cli.Repo)
+	if err != nil {
+		return cli.fail(remoteExitCode(err), err)
+	}
+
+	return cli.succeed("Pulled")
+}
+
+func (cli *CLI) sync() int {
+	err := cli.checkRepoOk("sync")
+	if err != nil {
+		return cli.fail(EXIT_REPO_NOT_OK, err)
+	}
+
+	err = core.GitFetch(cli.Repo)
+	if err != nil {
+		return cli.fail(remoteExitCode(err), err)
+	}
+
+	err = core.GitSmartPull(cli.Repo)
+	if err != nil {
+		return cli.fail(remoteExitCode(err), err)
+	}
+
+	err = core.GitPush(cli.Repo)
+	if err != nil {
+		return cli.fail(remoteExitCode(err), err)
+	}
+
+	return cli.succeed("Synced")
+}
+
+func (cli *CLI) parseLocks(args []string) (func() int, bool) {
+	if len(args) == 0 {
+		fmt.Fprint(cli.Stderr, USAGE)
+		return nil, false
+	}
+
+	switch args[0] {
+	case "list":
+		flags := cli.newFlagSet("locks list")
+		mine := flags.Bool("mine", false, "only the files locked by you")
+		err := flags.Parse(args[1:])
+		if err != nil || !cli.noExtraArgs(flags) {
+			return nil, false
+		}
+		return func() int { return cli.locksList(*mine) }, true
+	case "unlock":
+		flags := cli.newFlagSet("locks unlock")
+		force := flags.Bool("force", false, "unlock even if someone else owns the lock")
+		unchanged := flags.Bool("unchanged", false, "unlock every file locked by you that has no changes")
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return nil, false
+		}
+		ids := flags.Args()
+		if len(ids) == 0 && !*unchanged {
+			fmt.Fprint(cli.Stderr, USAGE)
+			return nil, false
+		}
+		if len(ids) > 0 && *unchanged {
+			fmt.Fprint(cli.Stderr, "Either ids or --unchanged for locks unlock, not both\n\n")
+			fmt.Fprint(cli.Stderr, USAGE)
+			return nil, false
+		}
+		return func() int { return cli.locksUnlock(ids, *force, *unchanged) }, true
+	}
+
+	fmt.Fprintf(cli.Stderr, "Unknown locks command: %s\n\n", args[0])
+	fmt.Fprint(cli.Stderr, USAGE)
+	return nil, false
+}
+
+type locksResult struct {
+	Ok    bool             `json:"ok"`
+	Locks []core.LockDatum `json:"locks"`
+}
+
+func (cli *CLI) locksList(mine bool) int {
+	user := ""
+	if mine {
+		var err error
+		user, err = core.GetUsernameFromRepo(cli.Repo)
+		if err != nil {
+			return cli.fail(EXIT_ERROR, err)
+		}
+		if user == "" {
+			return cli.fail(EXIT_ERROR, errNoUser)
+		}
+	}
+
+	locks, err := core.GetLockedFiles(cli.Repo, user)
+	if err != nil {
+		return cli.fail(EXIT_ERROR, err)
+	}
+
+	cli.print(locksResult{Ok: true, Locks: locks}, func(w io.Writer) {
+		for _, lock := range locks {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", lock.ID, lock.Owner.Name, lock.Path)
+		}
+	})
+	return EXIT_OK
+}
+
+type unlockResult struct {
+	Ok       bool     `json:"ok"`
+	Unlocked []string `json:"unlocked"`
+	Errors   []string `json:"errors"`
+}
+
+func (cli *CLI) locksUnlock(ids []string, force bool, unchanged bool) int {
+	var toUnlock []core.LockDatum
+	if unchanged {
+		unchangedLocks, err := core.ListLFSLockedUnchangedFiles(cli.Repo)
+		if err != nil {
+			return cli.fail(EXIT_ERROR, err)
+		}
+		user, err := core.GetUsernameFromRepo(cli.Repo)
+		if err != nil {
+			return cli.fail(EXIT_ERROR, err)
+		}
+		if user == "" {
+			return cli.fail(EXIT_ERROR, errNoUser)
+		}
+		for _, lock := range unchangedLocks {
+			if lock.Owner.Name == user {
+				toUnlock = append(toUnlock, lock)
+			}
+		}
+	} else {
+		allLocks, err := core.GetLockedFiles(cli.Repo, "")
+		if err != nil {
+			return cli.fail(EXIT_ERROR, err)
+		}
+		for _, id := range ids {
+			idx := slices.IndexFunc(allLocks, func(lock core.LockDatum) bool { return lock.ID == id || lock.Path == id })
+			if idx == -1 {
+				return cli.fail(EXIT_ERROR, fmt.Errorf("No lock found for %s", id))
+			}
+			toUnlock = append(toUnlock, allLocks[idx])
+		}
+	}
+
+	result := unlockResult{Ok: true, Unlocked: make([]string, 0), Errors: make([]string, 0)}
+	// one at a time so we know which ones failed
+	for _, lock := range toUnlock {
+		unlockErrors := core.UnlockLFSFiles(cli.Repo, []core.LockDatum{lock}, force)
+		if unlockErrors != nil {
+			result.Ok = false
+			result.Errors = append(result.Errors, lock.Path+": "+errors.Join(unlockErrors...).Error())
+		} else {
+			result.Unlocked = append(result.Unlocked, lock.Path)
+		}
+	}
+
+	cli.print(result, func(w io.Writer) {
+		for _, path := range result.Unlocked {
+			fmt.Fprintf(w, "unlocked %s\n", path)
+		}
+		for _, unlockError := range result.Errors {
+			fmt.Fprintf(cli.Stderr, "error: %s\n", unlockError)
+		}
+	})
+
+	if !result.Ok {
+		return EXIT_ERROR
+	}
+	return EXIT_OK
+}
+
+func (cli *CLI) fixConfig() int {
+	configStatus, err := core.GetGitConfigStatus(cli.Repo)
+	if err != nil {
+		return cli.fail(EXIT_ERROR, err)
+	}
+
+	switch configStatus {
+	case core.CONFIG_STATUS_MISSING:
+		err := core.CreateGitConfig(cli.Repo)
+		if err != nil {
+			return cli.fail(EXIT_ERROR, err)
+		}
+		return cli.succeed(".gitconfig created and linked")
+	case core.CONFIG_STATUS_NOT_LINKED:
+		err := core.LinkGitConfig(cli.Repo)
+		if err != nil {
+			return cli.fail(EXIT_ERROR, err)
+		}
+		return cli.succeed(".gitconfig linked")
+	}
+
+	return cli.succeed(".gitconfig already linked, nothing to do")
+}
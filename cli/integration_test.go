@@ -0,0 +1,71 @@
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/miltoncandelero/ugsg/cli"
+	"github.com/miltoncandelero/ugsg/internal/gittest"
+)
+
+// End to end against a local bare remote, see internal/gittest. Nothing fetches before the commands, they have to.
+
+func runOn(t *testing.T, wc *gittest.WorkingCopy, args ...string) int {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	command := &cli.CLI{Stdout: &stdout, Stderr: &stderr}
+	exitCode := command.Run(append([]string{"--repo", wc.Path}, args...))
+	t.Logf("ugsg %q: exit %d\n%s%s", args, exitCode, stdout.String(), stderr.String())
+	return exitCode
+}
+
+func TestPullFetchesFirst(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"README.md": "hi\n"})
+	alice.Push()
+	bob := remote.Clone("bob")
+	want := alice.Commit("Second", map[string]string{"Source/Game.cpp": "int main() {}\n"})
+	alice.Push()
+
+	if exitCode := runOn(t, bob, "pull"); exitCode != cli.EXIT_OK {
+		t.Fatalf("got exit code %d, want %d", exitCode, cli.EXIT_OK)
+	}
+	if bob.Head() != want {
+		t.Errorf("bob is on %s, want %s", bob.Head(), want)
+	}
+}
+
+func TestSyncFetchesFirst(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"README.md": "hi\n"})
+	alice.Push()
+	bob := remote.Clone("bob")
+	alice.Commit("Theirs", map[string]string{"Source/Game.cpp": "int main() {}\n"})
+	alice.Push()
+	bob.Commit("Mine", map[string]string{"Config/DefaultGame.ini": "[Game]\n"})
+
+	if exitCode := runOn(t, bob, "sync"); exitCode != cli.EXIT_OK {
+		t.Fatalf("got exit code %d, want %d", exitCode, cli.EXIT_OK)
+	}
+	if remote.Head() != bob.Head() {
+		t.Errorf("the remote is on %s, bob on %s", remote.Head(), bob.Head())
+	}
+	if bob.ReadFile("Source/Game.cpp") != "int main() {}\n" {
+		t.Error("alice's commit wasn't pulled before pushing")
+	}
+}
+
+func TestPullUnreachableServer(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"README.md": "hi\n"})
+	alice.Push()
+	// nothing listens on port 1
+	alice.Git("remote", "set-url", "origin", "http://127.0.0.1:1/remote.git")
+
+	if exitCode := runOn(t, alice, "pull"); exitCode != cli.EXIT_REMOTE {
+		t.Errorf("got exit code %d, want %d", exitCode, cli.EXIT_REMOTE)
+	}
+}
@@ -0,0 +1,12 @@
+// The command line alone, without the GUI and its cgo dependencies, for build machines
+package main
+
+import (
+	"os"
+
+	"github.com/miltoncandelero/ugsg/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:]))
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Where HEAD was before something we did moved it, so artists can undo without knowing about the reflog.
+// refs/ugsg/backup/<date>-<operation>/<branch>, HEAD as branch means it was deatached.
+const BACKUP_REF_PREFIX = "refs/ugsg/backup/"
+const BACKUP_DATE_FORMAT = "20060102-150405.000"
+
+// Older backups get deleted, the reflog still has them for a while
+const MAX_BACKUPS = 50
+
+const (
+	BACKUP_OPERATION_RESET     = "reset"
+	BACKUP_OPERATION_CHECKOUT  = "checkout"
+	BACKUP_OPERATION_PULL      = "pull"
+	BACKUP_OPERATION_LINEARIZE = "linearize"
+	BACKUP_OPERATION_RESTORE   = "restore"
+)
+
+type BackupDatum struct {
+	Ref       string
+	Hash      string
+	Date      time.Time
+	Operation string
+	// Branch checked out when the backup was made, HEAD if it was deatached
+	Branch string
+	// Subject of the commit the backup points to
+	Msg string
+}
+
+func CreateBackup(repo *Repo, operation string) (*BackupDatum, error) {
+	repo, unlock, err := repo.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	head, err := repo.executeStdout("rev-parse", "--verify", "HEAD")
+	if err != nil {
+		// nothing committed yet, nothing to lose
+		return nil, nil
+	}
+
+	snapshot, err := GetRepoSnapshot(repo, false)
+	if err != nil {
+		return nil, err
+	}
+	branch := snapshot.Branch
+	if snapshot.DeatachedHead {
+		branch = "HEAD"
+	}
+
+	backup := &BackupDatum{
+		Hash:      strings.TrimSpace(head),
+		Date:      time.Now(),
+		Operation: operation,
+		Branch:    branch,
+	}
+	backup.Ref = BACKUP_REF_PREFIX + backup.Date.Format(BACKUP_DATE_FORMAT) + "-" + operation + "/" + branch
+
+	_, err = repo.executeOneLine("update-ref", "-m", "ugsg backup before "+operation, backup.Ref, backup.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("Could not back up before %s: %w", operation, err)
+	}
+
+	pruneBackups(repo)
+
+	return backup, nil
+}
+
+// Newest first
+func GetBackups(repo *Repo) ([]BackupDatum, error) {
+	lines, err := repo.execute("for-each-ref", "--sort=-refname", "--format=%(refname)"+SEP+"%(objectname)"+SEP+"%(subject)", BACKUP_REF_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	retval := make([]BackupDatum, 0)
+	for _, line := range lines {
+		fields := strings.SplitN(strings.TrimSpace(line), SEP, 3)
+		if len(fields) != 3 {
+			continue
+		}
+		backup, ok := parseBackupRef(fields[0])
+		if !ok {
+			continue
+		}
+		backup.Hash = fields[1]
+		backup.Msg = fields[2]
+		retval = append(retval, backup)
+	}
+	return retval, nil
+}
+
+func parseBackupRef(ref string) (BackupDatum, bool) {
+	name, branch, found := strings.Cut(strings.TrimPrefix(ref, BACKUP_REF_PREFIX), "/")
+	if !found || len(name) <= len(BACKUP_DATE_FORMAT)+1 {
+		return BackupDatum{}, false
+	}
+	date, err := time.ParseInLocation(BACKUP_DATE_FORMAT, name[:len(BACKUP_DATE_FORMAT)], time.Local)
+	if err != nil {
+		return BackupDatum{}, false
+	}
+	return BackupDatum{
+		Ref:       ref,
+		Date:      date,
+		Operation: name[len(BACKUP_DATE_FORMAT)+1:],
+		Branch:    branch,
+	}, true
+}
+
+func pruneBackups(repo *Repo) {
+	backups, err := GetBackups(repo)
+	if err != nil || len(backups) <= MAX_BACKUPS {
+		return
+	}
+	for _, backup := range backups[MAX_BACKUPS:] {
+		repo.executeOneLine("update-ref", "-d", backup.Ref)
+	}
+}
+
+// Puts HEAD, and the branch it was on, back where the backup says. Backs up the current state first so this can be undone too.
+func RestoreBackup(repo *Repo, backup BackupDatum) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if IsUnrealRunning() {
+		return errors.New("Unreal is running, close it before undoing")
+	}
+
+	snapshot, err := GetRepoSnapshot(repo, false)
+	if err != nil {
+		return err
+	}
+	if snapshot.ChangeAmount() > 0 {
+		return errors.New("You have uncommited changes. Please commit (or discard) them before undoing")
+	}
+
+	_, err = CreateBackup(repo, BACKUP_OPERATION_RESTORE)
+	if err != nil {
+		return err
+	}
+
+	if backup.Branch == "HEAD" {
+		_, err = repo.executeProgress("checkout", "--detach", backup.Hash)
+	} else {
+		// moves the branch to the backup and checks it out in one go
+		_, err = repo.executeProgress("checkout", "-B", backup.Branch, backup.Hash)
+	}
+	return err
+}
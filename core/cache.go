@@ -0,0 +1,129 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Bump this whenever the way commits get classified changes so old caches get thrown away.
+// Changing the rules themselves is detected with their fingerprint.
+const COMMIT_CACHE_VERSION = 3
+const COMMIT_CACHE_FILE = "ugsg/commit-cache.json"
+
+type CommitCache struct {
+	Version          int                         `json:"version"`
+	RulesFingerprint string                      `json:"rules"`
+	Commits          map[string][]ChangeCategory `json:"commits"`
+
+	path  string
+	dirty bool
+	mutex sync.Mutex
+}
+
+// Caches are read from disk once and kept around, the classification of a commit never changes
+var commitCaches = make(map[string]*CommitCache)
+var commitCachesMutex sync.Mutex
+
+func GetCommitCachePath(repo *Repo) (string, error) {
+	paths, err := GetGitPaths(repo, COMMIT_CACHE_FILE)
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+func newCommitCache(path string, fingerprint string) *CommitCache {
+	return &CommitCache{
+		Version:          COMMIT_CACHE_VERSION,
+		RulesFingerprint: fingerprint,
+		Commits:          make(map[string][]ChangeCategory),
+		path:             path,
+	}
+}
+
+// Never fails, if the cache can't be read we start over with an empty one.
+func LoadCommitCache(repo *Repo) *CommitCache {
+	fingerprint := repo.Config.ClassificationRules.Fingerprint()
+
+	path, err := GetCommitCachePath(repo)
+	if err != nil {
+		// Not a repo? this cache won't be saved anywhere
+		return newCommitCache("", fingerprint)
+	}
+
+	commitCachesMutex.Lock()
+	defer commitCachesMutex.Unlock()
+
+	if cache, ok := commitCaches[path]; ok && cache.RulesFingerprint == fingerprint {
+		return cache
+	}
+
+	cache := newCommitCache(path, fingerprint)
+	contents, err := os.ReadFile(path)
+	if err == nil {
+		fromDisk := newCommitCache(path, fingerprint)
+		err = json.Unmarshal(contents, fromDisk)
+		if err == nil && fromDisk.Version == COMMIT_CACHE_VERSION && fromDisk.RulesFingerprint == fingerprint && fromDisk.Commits != nil {
+			cache = fromDisk
+		}
+	}
+
+	commitCaches[path] = cache
+	return cache
+}
+
+// Fills the classification of the commit if it is cached, returns false if it isn't.
+func (cache *CommitCache) Apply(commit *CommitDatum) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	categories, ok := cache.Commits[commit.Hash]
+	if !ok {
+		return false
+	}
+	commit.Categories = categories
+	return true
+}
+
+func (cache *CommitCache) Store(commit *CommitDatum) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.Commits[commit.Hash] = commit.Categories
+	cache.dirty = true
+}
+
+func (cache *CommitCache) Save() error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if !cache.dirty || cache.path == "" {
+		return nil
+	}
+
+	contents, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(cache.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// write and rename so a crash never leaves a half written cache behind
+	tmpPath := cache.path + ".tmp"
+	err = os.WriteFile(tmpPath, contents, 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, cache.path)
+	if err != nil {
+		return err
+	}
+
+	cache.dirty = false
+	return nil
+}
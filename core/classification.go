@@ -0,0 +1,81 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+type ChangeCategory string
+
+const (
+	CATEGORY_CONFIG  ChangeCategory = "Config"
+	CATEGORY_PLUGINS ChangeCategory = "Plugins"
+	CATEGORY_PROJECT ChangeCategory = "Project"
+	CATEGORY_SHADERS ChangeCategory = "Shaders"
+	CATEGORY_SOURCE  ChangeCategory = "Source"
+	CATEGORY_CONTENT ChangeCategory = "Content"
+)
+
+// A file gets the category of every rule it matches, so Plugins/Foo/Source/Bar.cpp is both Plugins and Source.
+type ClassificationRule struct {
+	Category ChangeCategory `json:"category"`
+	Pattern  string         `json:"pattern"`
+}
+
+type ClassificationRules []ClassificationRule
+
+func DefaultClassificationRules() ClassificationRules {
+	return ClassificationRules{
+		{Category: CATEGORY_CONFIG, Pattern: `^Config/.*\.ini$`},
+		{Category: CATEGORY_PLUGINS, Pattern: `^Plugins/`},
+		{Category: CATEGORY_PROJECT, Pattern: `^[^/]+\.uproject$`},
+		{Category: CATEGORY_SHADERS, Pattern: `(^|/)Shaders/|\.(usf|ush)$`},
+		{Category: CATEGORY_SOURCE, Pattern: `(^|/)Source/`},
+		{Category: CATEGORY_CONTENT, Pattern: `(^|/)Content/`},
+	}
+}
+
+type compiledRule struct {
+	category ChangeCategory
+	regex    *regexp.Regexp
+}
+
+type FileClassifier struct {
+	rules []compiledRule
+}
+
+func (rules ClassificationRules) Compile() (*FileClassifier, error) {
+	classifier := &FileClassifier{rules: make([]compiledRule, 0, len(rules))}
+	for _, rule := range rules {
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern for category %s: %w", rule.Category, err)
+		}
+		classifier.rules = append(classifier.rules, compiledRule{category: rule.Category, regex: regex})
+	}
+	return classifier, nil
+}
+
+// Changes whenever the rules change, used to throw away stale caches
+func (rules ClassificationRules) Fingerprint() string {
+	hash := sha1.New()
+	for _, rule := range rules {
+		fmt.Fprintf(hash, "%s\x00%s\x00", rule.Category, rule.Pattern)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (classifier *FileClassifier) ClassifyFile(commit *CommitDatum, file string) {
+	for _, rule := range classifier.rules {
+		if rule.regex.MatchString(file) && !commit.HasCategory(rule.category) {
+			commit.Categories = append(commit.Categories, rule.category)
+		}
+	}
+}
+
+func (commit *CommitDatum) HasCategory(category ChangeCategory) bool {
+	return slices.Contains(commit.Categories, category)
+}
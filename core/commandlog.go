@@ -0,0 +1,166 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type CommandEventKind int
+
+const (
+	COMMAND_STARTED CommandEventKind = iota
+	COMMAND_STDOUT
+	COMMAND_STDERR
+	COMMAND_EXITED
+)
+
+// Something that happened to a command we ran, every line of output is its own event
+type CommandEvent struct {
+	CommandID  int64
+	Kind       CommandEventKind
+	Time       time.Time
+	WorkingDir string
+	Cmd        string
+	Args       []string
+	// output line for COMMAND_STDOUT and COMMAND_STDERR
+	Line string
+	// for COMMAND_EXITED, -1 if it never got to run or was killed
+	ExitCode int
+	Duration time.Duration
+}
+
+func (event CommandEvent) CommandLine() string {
+	parts := make([]string, 0, len(event.Args)+1)
+	parts = append(parts, event.Cmd)
+	for _, arg := range event.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"") {
+			arg = "\"" + strings.ReplaceAll(arg, "\"", "\\\"") + "\""
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+var commandListeners = make(map[int64]func(CommandEvent))
+var commandListenersMutex sync.Mutex
+var nextCommandListenerID int64
+var nextCommandID atomic.Int64
+
+// The listener gets the events of every command from whatever goroutine runs it, it has to be quick and thread safe.
+// Call the returned function to stop listening.
+func AddCommandListener(listener func(CommandEvent)) func() {
+	commandListenersMutex.Lock()
+	defer commandListenersMutex.Unlock()
+
+	nextCommandListenerID++
+	id := nextCommandListenerID
+	commandListeners[id] = listener
+
+	return func() {
+		commandListenersMutex.Lock()
+		defer commandListenersMutex.Unlock()
+		delete(commandListeners, id)
+	}
+}
+
+func getCommandListeners() []func(CommandEvent) {
+	commandListenersMutex.Lock()
+	defer commandListenersMutex.Unlock()
+
+	listeners := make([]func(CommandEvent), 0, len(commandListeners))
+	for _, listener := range commandListeners {
+		listeners = append(listeners, listener)
+	}
+	return listeners
+}
+
+// Reports one command to the listeners. Nil when nobody is listening, all its methods do nothing then.
+type commandLog struct {
+	listeners []func(CommandEvent)
+	event     CommandEvent
+	started   time.Time
+}
+
+func startCommandLog(workingDir, command string, args []string) *commandLog {
+	listeners := getCommandListeners()
+	if len(listeners) == 0 {
+		return nil
+	}
+
+	log := &commandLog{
+		listeners: listeners,
+		started:   time.Now(),
+		event: CommandEvent{
+			CommandID:  nextCommandID.Add(1),
+			WorkingDir: workingDir,
+			Cmd:        command,
+			Args:       args,
+		},
+	}
+	log.emit(COMMAND_STARTED, "", 0)
+	return log
+}
+
+func (log *commandLog) emit(kind CommandEventKind, line string, exitCode int) {
+	if log == nil {
+		return
+	}
+	event := log.event
+	event.Kind = kind
+	event.Time = time.Now()
+	event.Line = line
+	event.ExitCode = exitCode
+	if kind == COMMAND_EXITED {
+		event.Duration = event.Time.Sub(log.started)
+	}
+	for _, listener := range log.listeners {
+		listener(event)
+	}
+}
+
+func (log *commandLog) line(kind CommandEventKind, line string) {
+	log.emit(kind, line, 0)
+}
+
+func (log *commandLog) exited(exitCode int) {
+	log.emit(COMMAND_EXITED, "", exitCode)
+}
+
+// Writer that turns whatever the command writes into one event per line
+func (log *commandLog) writer(kind CommandEventKind) *commandLogWriter {
+	return &commandLogWriter{log: log, kind: kind}
+}
+
+type commandLogWriter struct {
+	log  *commandLog
+	kind CommandEventKind
+	buf  []byte
+}
+
+func (writer *commandLogWriter) Write(p []byte) (int, error) {
+	if writer.log == nil {
+		return len(p), nil
+	}
+	writer.buf = append(writer.buf, p...)
+	for {
+		idx := bytes.IndexByte(writer.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		writer.log.line(writer.kind, strings.TrimSuffix(string(writer.buf[:idx]), "\r"))
+		writer.buf = writer.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Sends the last line if it didn't end in \n
+func (writer *commandLogWriter) Flush() {
+	if writer.log == nil || len(writer.buf) == 0 {
+		return
+	}
+	writer.log.line(writer.kind, strings.TrimSuffix(string(writer.buf), "\r"))
+	writer.buf = nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"errors"
+	"strings"
+)
+
+func GetFilesMissingLock(repo *Repo, files []string) ([]string, error) {
+	retval := make([]string, 0)
+	if len(files) == 0 {
+		return retval, nil
+	}
+
+	params := []string{"check-attr", "-z", "lockable", "--"}
+	params = append(params, files...)
+	out, err := repo.executeStdout(params...)
+	if err != nil {
+		return nil, err
+	}
+
+	lockableFiles := parseLockableAttributes(out)
+
+	if len(lockableFiles) == 0 {
+		// nothing lockable, no need to ask the server
+		return retval, nil
+	}
+
+	user, err := GetUsernameFromRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	ownLocks, err := GetLockedFiles(repo, user)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lockableFile := range lockableFiles {
+		isLocked := false
+		for _, lock := range ownLocks {
+			if lock.Path == lockableFile {
+				isLocked = true
+				break
+			}
+		}
+		if !isLocked {
+			retval = append(retval, lockableFile)
+		}
+	}
+
+	return retval, nil
+}
+
+func StageFiles(repo *Repo, files []string) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if len(files) == 0 {
+		return errors.New("No files selected")
+	}
+
+	// --all so deleted files get staged too
+	params := []string{"add", "--all", "--"}
+	params = append(params, files...)
+	_, err = repo.executeOneLine(params...)
+	return err
+}
+
+func Commit(repo *Repo, files []string, message string) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if strings.TrimSpace(message) == "" {
+		return errors.New("The commit message can't be empty")
+	}
+
+	if len(files) == 0 {
+		return errors.New("No files selected to commit")
+	}
+
+	if repo.Config.RequireLocksToCommit {
+		missingLock, err := GetFilesMissingLock(repo, files)
+		if err != nil {
+			return err
+		}
+		if len(missingLock) > 0 {
+			return errors.New("You don't hold the lock on these files, lock them before committing:\n" + strings.Join(missingLock, "\n"))
+		}
+	}
+
+	err = StageFiles(repo, files)
+	if err != nil {
+		return err
+	}
+
+	// Passing the files makes git commit only those, ignoring anything else that was already staged
+	params := []string{"commit", "--message", message, "--"}
+	params = append(params, files...)
+	_, err = repo.executeOneLine(params...)
+	return err
+}
@@ -1,31 +1,61 @@
-package core
-
-import (
-	"encoding/json"
-	"os"
-)
-
-const CONFIG_FILE = "ugsg.json"
-
-type Config struct {
-	ConfigPath  string `json:"-"`
-	GitExecPath string `json:"gitPath"`
-	RepoPath    string `json:"repoPath"`
-}
-
-func LoadConfig(path string) Config {
-	defaultConfig := &Config{
-		ConfigPath:  path,
-		GitExecPath: "git",
-		RepoPath:    "./",
-	}
-
-	contents, err := os.ReadFile(path)
-
-	if err != nil {
-		return *defaultConfig
-	}
-	json.Unmarshal(contents, defaultConfig)
-
-	return *defaultConfig
-}
+package core
+
+import (
+	"encoding/json"
+	"os"
+)
+
+const CONFIG_FILE = "ugsg.json"
+
+type Config struct {
+	ConfigPath          string              `json:"-"`
+	GitExecPath         string              `json:"gitPath"`
+	RepoPath            string              `json:"repoPath"`
+	ProjectFile         string              `json:"projectFile"`
+	RemoteName          string              `json:"remoteName"`
+	MainBranch          string              `json:"mainBranch"`
+	ClassificationRules ClassificationRules `json:"classificationRules"`
+
+	// Lock behavior
+	RequireLocksToCommit bool `json:"requireLocksToCommit"`
+	UnlockAfterPush      bool `json:"unlockAfterPush"`
+
+	// In seconds, 0 means no limit. The long one is for commands that move data around (fetch, push, checkout...),
+	// an hour by default so a stuck transfer doesn't hang forever
+	CommandTimeout     int `json:"commandTimeout"`
+	LongCommandTimeout int `json:"longCommandTimeout"`
+}
+
+func LoadConfig(path string) Config {
+	defaultConfig := &Config{
+		ConfigPath:  path,
+		GitExecPath: "git",
+		RepoPath:    "./",
+		RemoteName:  "origin",
+		MainBranch:  "main",
+		// replaced, not merged, when the file has its own rules
+		ClassificationRules:  DefaultClassificationRules(),
+		RequireLocksToCommit: true,
+		UnlockAfterPush:      false,
+		CommandTimeout:       300,
+		LongCommandTimeout:   3600,
+	}
+
+	contents, err := os.ReadFile(path)
+
+	if err != nil {
+		return *defaultConfig
+	}
+	json.Unmarshal(contents, defaultConfig)
+
+	return *defaultConfig
+}
+
+func SaveConfig(config Config) error {
+	contents, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.ConfigPath, contents, 0644)
+}
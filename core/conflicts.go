@@ -0,0 +1,128 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Unreal assets can't be merged, one side has to win
+var BINARY_ASSET_EXTENSIONS = []string{".uasset", ".umap"}
+
+type ConflictResolution int
+
+const (
+	CONFLICT_UNRESOLVED ConflictResolution = iota
+	CONFLICT_KEEP_MINE
+	CONFLICT_KEEP_THEIRS
+	// The user fixed the file by hand, only makes sense for text files
+	CONFLICT_KEEP_MERGED
+)
+
+// A file both sides of a rebase changed.
+// Mine is the local commit being replayed, theirs is what came from the remote.
+type ConflictDatum struct {
+	Path          string
+	MineAuthor    string
+	TheirsAuthor  string
+	MineDeleted   bool
+	TheirsDeleted bool
+	Binary        bool
+}
+
+func IsBinaryAsset(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, binaryExt := range BINARY_ASSET_EXTENSIONS {
+		if ext == binaryExt {
+			return true
+		}
+	}
+	return false
+}
+
+func GetRebaseConflicts(repo *Repo) ([]ConflictDatum, error) {
+	snapshot, err := GetRepoSnapshot(repo, false)
+	if err != nil {
+		return nil, err
+	}
+
+	retval := make([]ConflictDatum, 0)
+	for _, file := range snapshot.Files {
+		if !file.Conflicted {
+			continue
+		}
+		// While rebasing git's "ours" is the upstream we are replaying onto and "theirs" is our own commit
+		conflict := ConflictDatum{
+			Path:          file.Path,
+			TheirsDeleted: file.X == 'D',
+			MineDeleted:   file.Y == 'D',
+			Binary:        IsBinaryAsset(file.Path),
+		}
+		conflict.TheirsAuthor = getLastAuthor(repo, "HEAD", file.Path)
+		conflict.MineAuthor = getLastAuthor(repo, "REBASE_HEAD", file.Path)
+		retval = append(retval, conflict)
+	}
+	return retval, nil
+}
+
+func getLastAuthor(repo *Repo, rev string, path string) string {
+	author, err := repo.executeStdout("log", "-1", "--format=%an", rev, "--", path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(author)
+}
+
+func ResolveConflicts(repo *Repo, conflicts []ConflictDatum, resolutions map[string]ConflictResolution) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if IsUnrealRunning() {
+		return errors.New("Unreal is running, close it before resolving conflicts")
+	}
+
+	for _, conflict := range conflicts {
+		resolution := resolutions[conflict.Path]
+		if resolution == CONFLICT_UNRESOLVED {
+			continue
+		}
+		err := resolveConflict(repo, conflict, resolution)
+		if err != nil {
+			return fmt.Errorf("Could not resolve %s: %w", conflict.Path, err)
+		}
+	}
+	return nil
+}
+
+func resolveConflict(repo *Repo, conflict ConflictDatum, resolution ConflictResolution) error {
+	var err error
+	switch resolution {
+	case CONFLICT_KEEP_MINE:
+		if conflict.MineDeleted {
+			_, err = repo.executeOneLine("rm", "--quiet", "--", conflict.Path)
+			return err
+		}
+		// git's theirs, see GetRebaseConflicts
+		_, err = repo.executeOneLine("checkout", "--theirs", "--", conflict.Path)
+	case CONFLICT_KEEP_THEIRS:
+		if conflict.TheirsDeleted {
+			_, err = repo.executeOneLine("rm", "--quiet", "--", conflict.Path)
+			return err
+		}
+		_, err = repo.executeOneLine("checkout", "--ours", "--", conflict.Path)
+	case CONFLICT_KEEP_MERGED:
+		if conflict.Binary {
+			return errors.New("Binary assets can't be merged, keep one side")
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.executeOneLine("add", "--", conflict.Path)
+	return err
+}
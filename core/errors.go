@@ -0,0 +1,211 @@
+package core
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotARepo   = errors.New("This is not a git repository")
+	ErrGitMissing = errors.New("Git is not installed, or not where the project settings say it is")
+	// ERROR_KIND_REMOTE_HUNG_UP errors are this too
+	ErrNetwork = errors.New("Could not reach the server")
+	// ERROR_KIND_AUTH_FAILED errors are this too
+	ErrAuth = errors.New("The server didn't accept your credentials")
+)
+
+type ErrorKind int
+
+const (
+	ERROR_KIND_UNKNOWN ErrorKind = iota
+	ERROR_KIND_AUTH_FAILED
+	ERROR_KIND_NON_FAST_FORWARD
+	ERROR_KIND_LOCKED_BY_OTHER
+	ERROR_KIND_MISSING_LFS_OBJECT
+	ERROR_KIND_DISK_FULL
+	ERROR_KIND_INDEX_LOCKED
+	ERROR_KIND_REMOTE_HUNG_UP
+)
+
+func (kind ErrorKind) String() string {
+	switch kind {
+	case ERROR_KIND_AUTH_FAILED:
+		return "auth-failed"
+	case ERROR_KIND_NON_FAST_FORWARD:
+		return "non-fast-forward"
+	case ERROR_KIND_LOCKED_BY_OTHER:
+		return "locked-by-other"
+	case ERROR_KIND_MISSING_LFS_OBJECT:
+		return "missing-lfs-object"
+	case ERROR_KIND_DISK_FULL:
+		return "disk-full"
+	case ERROR_KIND_INDEX_LOCKED:
+		return "index-locked"
+	case ERROR_KIND_REMOTE_HUNG_UP:
+		return "remote-hung-up"
+	}
+	return "unknown"
+}
+
+// Something the user can do about an error, the GUI offers it as a button
+type FixAction int
+
+const (
+	FIX_ACTION_NONE FixAction = iota
+	FIX_ACTION_PULL
+	FIX_ACTION_MANAGE_LOCKS
+	FIX_ACTION_REMOVE_INDEX_LOCK
+	FIX_ACTION_PRUNE_LFS
+	FIX_ACTION_OPEN_TERMINAL
+)
+
+func (fix FixAction) Label() string {
+	switch fix {
+	case FIX_ACTION_PULL:
+		return "Pull now"
+	case FIX_ACTION_MANAGE_LOCKS:
+		return "Show locks"
+	case FIX_ACTION_REMOVE_INDEX_LOCK:
+		return "Remove the lock"
+	case FIX_ACTION_PRUNE_LFS:
+		return "Free up LFS space"
+	case FIX_ACTION_OPEN_TERMINAL:
+		return "Open a terminal"
+	}
+	return ""
+}
+
+// A failure we recognized, explained in plain words. The original error is kept for the details.
+type ActionableError struct {
+	Kind        ErrorKind
+	Explanation string
+	Fix         FixAction
+	Err         error
+}
+
+func (e *ActionableError) Error() string {
+	return e.Explanation
+}
+
+func (e *ActionableError) Unwrap() error {
+	return e.Err
+}
+
+// So callers can check errors.Is(err, ErrAuth) without knowing about kinds
+func (e *ActionableError) Is(target error) bool {
+	switch e.Kind {
+	case ERROR_KIND_AUTH_FAILED:
+		return target == ErrAuth
+	case ERROR_KIND_REMOTE_HUNG_UP:
+		return target == ErrNetwork
+	}
+	return false
+}
+
+// Stands for the remote name in explanations
+const REMOTE_PLACEHOLDER = "<remote>"
+
+type errorPattern struct {
+	kind ErrorKind
+	// REMOTE_PLACEHOLDER in it is replaced with the configured remote
+	explanation string
+	fix         FixAction
+	// any of these, lowercase, in the output
+	needles []string
+}
+
+// First match wins, so more specific ones go first (a 403 is "unable to access" too)
+var errorPatterns = []errorPattern{
+	{
+		kind:        ERROR_KIND_DISK_FULL,
+		explanation: "The disk is full. Free up some space and try again, old LFS files you no longer need can be removed.",
+		fix:         FIX_ACTION_PRUNE_LFS,
+		needles:     []string{"no space left on device", "not enough space on the disk", "there is not enough space", "disk quota exceeded"},
+	},
+	{
+		kind:        ERROR_KIND_INDEX_LOCKED,
+		explanation: "The repository is locked by another git program (index.lock). Wait for it to finish or close it, if nothing is running the lock was left behind and can be removed.",
+		fix:         FIX_ACTION_REMOVE_INDEX_LOCK,
+		needles:     []string{"index.lock", "another git process seems to be running"},
+	},
+	{
+		kind:        ERROR_KIND_AUTH_FAILED,
+		explanation: "The server didn't accept your credentials. Open a terminal and run \"git fetch\" to log in again, or check that your account has access to the repository.",
+		fix:         FIX_ACTION_OPEN_TERMINAL,
+		needles: []string{"authentication failed", "could not read username", "could not read password", "terminal prompts disabled",
+			"permission denied (publickey", "invalid username or password", "host key verification failed",
+			"the requested url returned error: 401", "the requested url returned error: 403", "access denied"},
+	},
+	{
+		kind:        ERROR_KIND_LOCKED_BY_OTHER,
+		explanation: "Someone else has a lock on files you changed. Ask them to unlock them, or check the locks to see who has them.",
+		fix:         FIX_ACTION_MANAGE_LOCKS,
+		needles:     []string{"unable to push locked files", "cannot update locked files", "locked by", "lock exists"},
+	},
+	{
+		kind:        ERROR_KIND_MISSING_LFS_OBJECT,
+		explanation: "A file stored in LFS is missing from the server, whoever committed it didn't upload it. Ask them to run \"git lfs push --all " + REMOTE_PLACEHOLDER + "\".",
+		fix:         FIX_ACTION_NONE,
+		needles:     []string{"does not exist on the server", "smudge filter lfs failed", "error downloading object", "missing object", "object not found"},
+	},
+	{
+		kind:        ERROR_KIND_NON_FAST_FORWARD,
+		explanation: "The server has commits you don't have yet. Pull first, then try again.",
+		fix:         FIX_ACTION_PULL,
+		needles:     []string{"non-fast-forward", "[rejected]", "fetch first", "updates were rejected", "not possible to fast-forward"},
+	},
+	{
+		kind:        ERROR_KIND_REMOTE_HUNG_UP,
+		explanation: "The connection to the server was lost. Check your internet connection and try again.",
+		fix:         FIX_ACTION_NONE,
+		needles: []string{"the remote end hung up unexpectedly", "early eof", "connection reset", "rpc failed", "could not resolve host",
+			"connection timed out", "failed to connect", "unable to access", "could not read from remote repository"},
+	},
+}
+
+// Turns the errors we know into an *ActionableError, anything else comes back untouched.
+// Git errors coming out of core already went through here with the remote of their repo.
+func ClassifyError(err error) error {
+	return classifyError(err, "")
+}
+
+// An empty remote leaves REMOTE_PLACEHOLDER in the explanation
+func classifyError(err error, remote string) error {
+	if err == nil {
+		return nil
+	}
+
+	var actionable *ActionableError
+	if errors.As(err, &actionable) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, ErrStaleIndexLock):
+		return &ActionableError{Kind: ERROR_KIND_INDEX_LOCKED, Explanation: ErrStaleIndexLock.Error(), Fix: FIX_ACTION_REMOVE_INDEX_LOCK, Err: err}
+	case errors.Is(err, ErrIndexLocked):
+		return &ActionableError{Kind: ERROR_KIND_INDEX_LOCKED, Explanation: ErrIndexLocked.Error(), Fix: FIX_ACTION_NONE, Err: err}
+	case errors.Is(err, ErrPushBehind):
+		return &ActionableError{Kind: ERROR_KIND_NON_FAST_FORWARD, Explanation: "You are behind the server. Pull first, then push.", Fix: FIX_ACTION_PULL, Err: err}
+	}
+
+	// our own errors are already readable, only git's output needs explaining
+	var execErr ErrExec
+	if !errors.As(err, &execErr) {
+		return err
+	}
+	output := strings.ToLower(execErr.Output + "\n" + execErr.ErrOutput)
+
+	for _, pattern := range errorPatterns {
+		for _, needle := range pattern.needles {
+			if strings.Contains(output, needle) {
+				explanation := pattern.explanation
+				if remote != "" {
+					explanation = strings.ReplaceAll(explanation, REMOTE_PLACEHOLDER, remote)
+				}
+				return &ActionableError{Kind: pattern.kind, Explanation: explanation, Fix: pattern.fix, Err: err}
+			}
+		}
+	}
+	return err
+}
@@ -1,138 +1,401 @@
-package core
-
-import (
-	"bytes"
-	"errors"
-	"fmt"
-	"io"
-	"os"
-	"os/exec"
-	"strings"
-
-	"github.com/go-cmd/cmd"
-)
-
-var (
-	ErrApplicationNotFound = errors.New("application not found")
-	//ErrApplicationFailed   = errors.New("application execution failed")
-)
-
-type ErrExec struct {
-	ExitCode  int
-	Output    string
-	ErrOutput string
-	Cmd       string
-	Args      []string
-}
-
-func (e ErrExec) Error() string {
-	out := e.Output
-	if len(e.ErrOutput) > len(out) {
-		out = e.ErrOutput
-	}
-	return fmt.Sprintf("application execution failed: %s %s: rc %d: %s",
-		e.Cmd,
-		strings.Join(e.Args, " "),
-		e.ExitCode,
-		out,
-	)
-}
-
-func Execute(workingDir, command string, args ...string) ([]string, error) {
-
-	outStr, err := ExecuteOneLine(workingDir, command, args...)
-
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(outStr, "\n")
-	for idx, line := range lines {
-		// lines[idx] = strings.TrimSpace(line)
-		lines[idx] = line
-	}
-
-	return lines, nil
-}
-
-func ExecuteOneLine(workingDir, command string, args ...string) (string, error) {
-	_, err := exec.LookPath(command)
-	if err != nil {
-		return "", fmt.Errorf("%w: %s", ErrApplicationNotFound, command)
-	}
-
-	c := exec.Command(command, args...)
-	if workingDir != "" {
-		c.Dir = workingDir
-	}
-	c.Env = os.Environ()
-
-	// combined contains stdout and stderr but stderr only contains stderr output
-	combinedOut := &bytes.Buffer{}
-	stderrBuf := &bytes.Buffer{}
-
-	c.Stderr = io.MultiWriter(combinedOut, stderrBuf)
-	c.Stdout = combinedOut
-
-	// fmt.Printf("Executing: %s\n", c.String())
-
-	err = c.Run()
-	if err != nil {
-		return "", ErrExec{
-			ExitCode:  c.ProcessState.ExitCode(),
-			Output:    strings.TrimSpace(combinedOut.String()),
-			ErrOutput: strings.TrimSpace(stderrBuf.String()),
-			Cmd:       command,
-			Args:      args,
-		}
-	}
-
-	outStr := combinedOut.String()
-	return outStr, nil
-}
-
-func ExecuteNonBlocking(workingDir, command string, args ...string) (*cmd.Cmd, <-chan cmd.Status, error) {
-	_, err := exec.LookPath(command)
-	if err != nil {
-		return nil, nil, fmt.Errorf("%w: %s", ErrApplicationNotFound, command)
-	}
-
-	c := cmd.NewCmd(command, args...)
-	if workingDir != "" {
-		c.Dir = workingDir
-	}
-	c.Env = os.Environ()
-
-	statusChan := c.Start()
-
-	return c, statusChan, nil
-}
-
-func OpenCmd(workingDir string) error {
-	fmt.Printf("\"opening cmd\": %v\n", "opening cmd")
-
-	cmd := os.Getenv("COMSPEC")
-	if cmd != "" {
-		// Windows here
-		powershell := "powershell.exe"
-		if _, err := exec.LookPath(powershell); err == nil {
-			c := exec.Command(cmd, "/c", "start", powershell)
-			if workingDir != "" {
-				c.Dir = workingDir
-			}
-			err := c.Run()
-			return err
-		}
-
-		c := exec.Command(cmd, "/c", "start", cmd)
-		if workingDir != "" {
-			c.Dir = workingDir
-		}
-
-		err := c.Run()
-
-		return err
-	}
-
-	return errors.New("No idea how to summon a shell in Linux or OSX.\nPlease send feedback if you do!")
-}
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-cmd/cmd"
+)
+
+var (
+	ErrApplicationNotFound = errors.New("application not found")
+	//ErrApplicationFailed   = errors.New("application execution failed")
+)
+
+type ErrExec struct {
+	ExitCode  int
+	Output    string
+	ErrOutput string
+	Cmd       string
+	Args      []string
+}
+
+func (e ErrExec) Error() string {
+	out := e.Output
+	if len(e.ErrOutput) > len(out) {
+		out = e.ErrOutput
+	}
+	return fmt.Sprintf("application execution failed: %s %s: rc %d: %s",
+		e.Cmd,
+		strings.Join(e.Args, " "),
+		e.ExitCode,
+		out,
+	)
+}
+
+// Set on every command we run, nobody is there to answer a prompt or close an editor
+var COMMAND_ENV = []string{
+	"GIT_TERMINAL_PROMPT=0",
+	"GIT_EDITOR=true",
+	"GIT_SEQUENCE_EDITOR=true",
+	"GIT_MERGE_AUTOEDIT=no",
+	// reading the status doesn't need to fight other gits for index.lock
+	"GIT_OPTIONAL_LOCKS=0",
+	// git's messages in english whatever the user's language, errors are recognized by their text
+	"LC_ALL=C",
+}
+
+// How long a process gets after being killed to let go of its output before we stop waiting
+const KILL_WAIT_DELAY = 5 * time.Second
+
+// The commandLog reports the command to the console listeners, finish it once the command is done
+func newCommand(ctx context.Context, workingDir, command string, args ...string) (*exec.Cmd, *commandLog, error) {
+	log := startCommandLog(workingDir, command, args)
+
+	_, err := exec.LookPath(command)
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrApplicationNotFound, command)
+		log.line(COMMAND_STDERR, err.Error())
+		log.exited(-1)
+		return nil, nil, err
+	}
+
+	c := exec.CommandContext(ctx, command, args...)
+	if workingDir != "" {
+		c.Dir = workingDir
+	}
+	c.Env = append(os.Environ(), COMMAND_ENV...)
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		// a passphrase prompt on a terminal nobody sees would hang forever
+		c.Env = append(c.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	// git spawns helpers (lfs, credential managers, ssh), they have to die with it
+	killProcessTreeOnCancel(c)
+	c.WaitDelay = KILL_WAIT_DELAY
+
+	return c, log, nil
+}
+
+// Flushes the output writers and reports the exit code
+func (log *commandLog) finish(c *exec.Cmd, writers ...*commandLogWriter) {
+	for _, writer := range writers {
+		writer.Flush()
+	}
+	exitCode := -1
+	if c.ProcessState != nil {
+		exitCode = c.ProcessState.ExitCode()
+	}
+	log.exited(exitCode)
+}
+
+// Stdout and stderr are copied by different goroutines, a buffer they both write to needs a lock
+type syncWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func (writer *syncWriter) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	return writer.writer.Write(p)
+}
+
+// Tells a cancelled or timed out command apart from one that failed on its own
+func contextError(ctx context.Context, command string, args []string) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return fmt.Errorf("%s %s took too long and was stopped: %w", command, strings.Join(args, " "), ctx.Err())
+	}
+	return fmt.Errorf("%s %s was cancelled: %w", command, strings.Join(args, " "), ctx.Err())
+}
+
+func Execute(workingDir, command string, args ...string) ([]string, error) {
+	return ExecuteContext(context.Background(), workingDir, command, args...)
+}
+
+func ExecuteContext(ctx context.Context, workingDir, command string, args ...string) ([]string, error) {
+
+	outStr, err := ExecuteOneLineContext(ctx, workingDir, command, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(outStr, "\n")
+	for idx, line := range lines {
+		// lines[idx] = strings.TrimSpace(line)
+		lines[idx] = line
+	}
+
+	return lines, nil
+}
+
+func ExecuteOneLine(workingDir, command string, args ...string) (string, error) {
+	return ExecuteOneLineContext(context.Background(), workingDir, command, args...)
+}
+
+func ExecuteOneLineContext(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	c, log, err := newCommand(ctx, workingDir, command, args...)
+	if err != nil {
+		return "", err
+	}
+
+	// combined contains stdout and stderr but stderr only contains stderr output
+	combinedOut := &bytes.Buffer{}
+	stderrBuf := &bytes.Buffer{}
+	combinedWriter := &syncWriter{writer: combinedOut}
+
+	stdoutLog := log.writer(COMMAND_STDOUT)
+	stderrLog := log.writer(COMMAND_STDERR)
+	c.Stderr = io.MultiWriter(combinedWriter, stderrBuf, stderrLog)
+	c.Stdout = io.MultiWriter(combinedWriter, stdoutLog)
+
+	err = c.Run()
+	log.finish(c, stdoutLog, stderrLog)
+	if ctxErr := contextError(ctx, command, args); ctxErr != nil {
+		return "", ctxErr
+	}
+	if err != nil {
+		return "", ErrExec{
+			ExitCode:  c.ProcessState.ExitCode(),
+			Output:    strings.TrimSpace(combinedOut.String()),
+			ErrOutput: strings.TrimSpace(stderrBuf.String()),
+			Cmd:       command,
+			Args:      args,
+		}
+	}
+
+	outStr := combinedOut.String()
+	return outStr, nil
+}
+
+// Like ExecuteOneLine but only returns stdout, for output we have to parse and warnings would break
+func ExecuteStdout(workingDir, command string, args ...string) (string, error) {
+	return ExecuteStdoutContext(context.Background(), workingDir, command, args...)
+}
+
+func ExecuteStdoutContext(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	c, log, err := newCommand(ctx, workingDir, command, args...)
+	if err != nil {
+		return "", err
+	}
+
+	stdoutBuf := &bytes.Buffer{}
+	stderrBuf := &bytes.Buffer{}
+	stdoutLog := log.writer(COMMAND_STDOUT)
+	stderrLog := log.writer(COMMAND_STDERR)
+	c.Stdout = io.MultiWriter(stdoutBuf, stdoutLog)
+	c.Stderr = io.MultiWriter(stderrBuf, stderrLog)
+
+	err = c.Run()
+	log.finish(c, stdoutLog, stderrLog)
+	if ctxErr := contextError(ctx, command, args); ctxErr != nil {
+		return "", ctxErr
+	}
+	if err != nil {
+		return "", ErrExec{
+			ExitCode:  c.ProcessState.ExitCode(),
+			Output:    strings.TrimSpace(stdoutBuf.String()),
+			ErrOutput: strings.TrimSpace(stderrBuf.String()),
+			Cmd:       command,
+			Args:      args,
+		}
+	}
+
+	return stdoutBuf.String(), nil
+}
+
+// Runs the command and calls lineCallback for every stdout line as soon as it is read.
+// If lineCallback returns an error the process is killed and that error is returned.
+func ExecuteStream(workingDir string, stdin io.Reader, lineCallback func(string) error, command string, args ...string) error {
+	return ExecuteStreamContext(context.Background(), workingDir, stdin, lineCallback, command, args...)
+}
+
+func ExecuteStreamContext(ctx context.Context, workingDir string, stdin io.Reader, lineCallback func(string) error, command string, args ...string) error {
+	c, log, err := newCommand(ctx, workingDir, command, args...)
+	if err != nil {
+		return err
+	}
+	c.Stdin = stdin
+
+	stderrBuf := &bytes.Buffer{}
+	stderrLog := log.writer(COMMAND_STDERR)
+	c.Stderr = io.MultiWriter(stderrBuf, stderrLog)
+
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = c.Start()
+	if err != nil {
+		log.line(COMMAND_STDERR, err.Error())
+		log.finish(c)
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	// long paths and commit messages, we don't want to choke on them
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var callbackErr error
+	for scanner.Scan() {
+		log.line(COMMAND_STDOUT, scanner.Text())
+		callbackErr = lineCallback(scanner.Text())
+		if callbackErr != nil {
+			c.Process.Kill()
+			break
+		}
+	}
+	if callbackErr == nil && scanner.Err() != nil {
+		callbackErr = scanner.Err()
+		c.Process.Kill()
+	}
+	// drain whatever is left so Wait doesn't hang
+	io.Copy(io.Discard, stdout)
+
+	err = c.Wait()
+	log.finish(c, stderrLog)
+	if ctxErr := contextError(ctx, command, args); ctxErr != nil {
+		return ctxErr
+	}
+	if callbackErr != nil {
+		return callbackErr
+	}
+	if err != nil {
+		return ErrExec{
+			ExitCode:  c.ProcessState.ExitCode(),
+			Output:    strings.TrimSpace(stderrBuf.String()),
+			ErrOutput: strings.TrimSpace(stderrBuf.String()),
+			Cmd:       command,
+			Args:      args,
+		}
+	}
+
+	return nil
+}
+
+// Runs the command with its progress forced on and calls onProgress for every progress line on stderr.
+// The returned output is stdout and whatever stderr said that wasn't progress.
+func ExecuteProgressContext(ctx context.Context, workingDir string, onProgress func(Progress), command string, args ...string) (string, error) {
+	c, log, err := newCommand(ctx, workingDir, command, args...)
+	if err != nil {
+		return "", err
+	}
+	// lfs only draws its meters on a terminal otherwise
+	c.Env = append(c.Env, "GIT_LFS_FORCE_PROGRESS=1")
+
+	stdoutBuf := &bytes.Buffer{}
+	stdoutLog := log.writer(COMMAND_STDOUT)
+	c.Stdout = io.MultiWriter(stdoutBuf, stdoutLog)
+
+	stderr, err := c.StderrPipe()
+	if err != nil {
+		return "", err
+	}
+
+	err = c.Start()
+	if err != nil {
+		log.line(COMMAND_STDERR, err.Error())
+		log.finish(c)
+		return "", err
+	}
+
+	stderrBuf := &bytes.Buffer{}
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		progress, ok := ParseProgressLine(line)
+		if ok {
+			onProgress(progress)
+			// only the last line of each phase, the console doesn't need every percent
+			if progress.Done {
+				log.line(COMMAND_STDERR, line)
+			}
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			stderrBuf.WriteString(line + "\n")
+			log.line(COMMAND_STDERR, line)
+		}
+	}
+	// drain whatever is left so Wait doesn't hang
+	io.Copy(io.Discard, stderr)
+
+	err = c.Wait()
+	log.finish(c, stdoutLog)
+	if ctxErr := contextError(ctx, command, args); ctxErr != nil {
+		return "", ctxErr
+	}
+	combined := stdoutBuf.String() + stderrBuf.String()
+	if err != nil {
+		return "", ErrExec{
+			ExitCode:  c.ProcessState.ExitCode(),
+			Output:    strings.TrimSpace(combined),
+			ErrOutput: strings.TrimSpace(stderrBuf.String()),
+			Cmd:       command,
+			Args:      args,
+		}
+	}
+
+	return combined, nil
+}
+
+// Only splits on \n, git progress lines end in \r so use ExecuteProgressContext for those
+func ExecuteNonBlocking(workingDir, command string, args ...string) (*cmd.Cmd, <-chan cmd.Status, error) {
+	_, err := exec.LookPath(command)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrApplicationNotFound, command)
+	}
+
+	c := cmd.NewCmd(command, args...)
+	if workingDir != "" {
+		c.Dir = workingDir
+	}
+	c.Env = append(os.Environ(), COMMAND_ENV...)
+
+	statusChan := c.Start()
+
+	return c, statusChan, nil
+}
+
+func OpenCmd(workingDir string) error {
+	fmt.Printf("\"opening cmd\": %v\n", "opening cmd")
+
+	cmd := os.Getenv("COMSPEC")
+	if cmd != "" {
+		// Windows here
+		powershell := "powershell.exe"
+		if _, err := exec.LookPath(powershell); err == nil {
+			c := exec.Command(cmd, "/c", "start", powershell)
+			if workingDir != "" {
+				c.Dir = workingDir
+			}
+			err := c.Run()
+			return err
+		}
+
+		c := exec.Command(cmd, "/c", "start", cmd)
+		if workingDir != "" {
+			c.Dir = workingDir
+		}
+
+		err := c.Run()
+
+		return err
+	}
+
+	return errors.New("No idea how to summon a shell in Linux or OSX.\nPlease send feedback if you do!")
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package core
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// taskkill /T takes the children with it, Process.Kill would leave them running
+func killProcessTreeOnCancel(c *exec.Cmd) {
+	c.Cancel = func() error {
+		err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(c.Process.Pid)).Run()
+		if err != nil {
+			return c.Process.Kill()
+		}
+		return nil
+	}
+}
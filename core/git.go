@@ -1,481 +1,827 @@
-package core
-
-import (
-	"errors"
-	"log"
-	"os"
-	"path/filepath"
-	"regexp"
-	"strconv"
-	"strings"
-	"time"
-
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
-)
-
-type CommitDatum struct {
-	Hash          string
-	Msg           string
-	User          string
-	Date          time.Time
-	ContentChange bool
-	SourceChange  bool
-}
-
-const GIT = "git"
-const SEP = "§"
-const ORIGIN = "origin"
-
-var SOURCE_REGEX = regexp.MustCompile(`.*(\\|\/)?Source((\\|\/).*)?`)
-var CONTENT_REGEX = regexp.MustCompile(`.*(\\|\/)?Content((\\|\/).*)?`)
-
-func GetRepoBranchInfo(repoPath string, branchName string) ([]*CommitDatum, error) {
-	start := time.Now()
-
-	r, err := git.PlainOpen(repoPath)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	commitsIter, _ := r.Log(&git.LogOptions{})
-
-	commitData := make([]*CommitDatum, 0)
-
-	commitsIter.ForEach(func(c *object.Commit) error {
-
-		newCommit := &CommitDatum{
-			Hash: c.Hash.String(),
-			Msg:  strings.Split(c.Message, "\n")[0],
-			User: c.Author.Name,
-			Date: c.Author.When,
-		}
-
-		// git command faster than go-git
-		// show vs diff-tree? Show works with initial commit. Diff-tree does not.
-		// git --no-pager show <sha> --name-only --format=""
-		// git --no-pager diff-tree --no-commit-id --name-only -r <sha> --
-		diffLines, _ := Execute(repoPath, GIT, "show", newCommit.Hash, "--name-only", "--format=")
-		// diffLines, _ := Execute(repoPath, GIT, "diff-tree", "--no-commit-id", "--name-only", "-r", newCommit.Hash, "--")
-
-		for _, file := range diffLines {
-			if CONTENT_REGEX.MatchString(file) {
-				newCommit.ContentChange = true
-			}
-			if SOURCE_REGEX.MatchString(file) {
-				newCommit.SourceChange = true
-			}
-
-			if newCommit.ContentChange && newCommit.SourceChange {
-				break
-			}
-		}
-		commitData = append(commitData, newCommit)
-
-		return nil
-	})
-
-	elapsed := time.Since(start)
-	log.Printf("Git took %s", elapsed)
-
-	return commitData, nil
-
-}
-
-func GetCurrentBranchFromRepository(repoPath string) (string, error) {
-
-	repository, _ := git.PlainOpen(repoPath)
-
-	branchRefs, err := repository.Branches()
-	if err != nil {
-		return "", err
-	}
-
-	headRef, err := repository.Head()
-	if err != nil {
-		return "", err
-	}
-
-	var currentBranchName string
-	err = branchRefs.ForEach(func(branchRef *plumbing.Reference) error {
-		if branchRef.Hash() == headRef.Hash() {
-			currentBranchName = branchRef.Name().String()
-
-			return nil
-		}
-
-		return nil
-	})
-	if err != nil {
-		return "", err
-	}
-
-	return currentBranchName, nil
-}
-
-func LinkGitConfig(repoPath string) error {
-	if !FileExists(filepath.Join(repoPath, ".gitconfig")) {
-		return errors.New(".gitconfig file missing!")
-	}
-
-	_, err := Execute(repoPath, GIT, "config", "--local", "include.path", ".gitconfig")
-	return err
-}
-
-func CreateGitConfig(repoPath string) error {
-	if FileExists(filepath.Join(repoPath, ".gitconfig")) {
-		return errors.New(".gitconfig already exists!")
-	}
-
-	f, err := os.Create(filepath.Join(repoPath, ".gitconfig"))
-
-	if err != nil {
-		return err
-	}
-
-	_, err2 := f.WriteString(GetConfigString())
-
-	if err2 != nil {
-		return err2
-	}
-
-	f.Close()
-
-	LinkGitConfig(repoPath)
-
-	return nil
-}
-
-type GitConfigStatus int
-
-const (
-	CONFIG_STATUS_MISSING GitConfigStatus = iota
-	CONFIG_STATUS_NOT_LINKED
-	CONFIG_STATUS_LINKED
-)
-
-func GetGitConfigStatus(repoPath string) GitConfigStatus {
-	exists := FileExists(filepath.Join(repoPath, ".gitconfig"))
-	if !exists {
-		return CONFIG_STATUS_MISSING
-	}
-	_, err := Execute(repoPath, GIT, "config", "--local", "include.path")
-	if err != nil {
-		return CONFIG_STATUS_NOT_LINKED
-	}
-
-	return CONFIG_STATUS_LINKED
-}
-
-func NeedsUsernameFix(repoPath string) bool {
-	username, _ := ExecuteOneLine(repoPath, GIT, "config", "--local", "user.name")
-	if username == "" {
-		return true
-	}
-	email, _ := ExecuteOneLine(repoPath, GIT, "config", "--local", "user.email")
-	if email == "" {
-		return true
-	}
-	return false
-}
-
-func GetUsernameFromRepo(repoPath string) string {
-	username, _ := ExecuteOneLine(repoPath, GIT, "config", "--local", "user.name")
-	return strings.TrimSpace(username)
-}
-
-func GetUserEmailFromRepo(repoPath string) string {
-	email, _ := ExecuteOneLine(repoPath, GIT, "config", "--local", "user.email")
-	return strings.TrimSpace(email)
-}
-
-func SetUsernameAndEmail(repoPath string, username string, email string) error {
-	_, err := ExecuteOneLine(repoPath, GIT, "config", "--local", "user.name", username)
-	if err != nil {
-		return err
-	}
-	_, err2 := ExecuteOneLine(repoPath, GIT, "config", "--local", "user.email", email)
-	if err2 != nil {
-		return err2
-	}
-	return nil
-}
-
-func GetGitProviderName(repoPath string) string {
-	// Probably fails if you have many remotes
-
-	remotes, _ := ExecuteOneLine(repoPath, GIT, "remote", "get-url", ORIGIN)
-
-	if strings.Contains(remotes, "github") {
-		return "GitHub"
-	}
-	if strings.Contains(remotes, "gitlab") {
-		return "GitLab"
-	}
-	if strings.Contains(remotes, "gitea") {
-		return "Gitea" // probably not correct, but what can I do?
-	}
-
-	return "Unknown"
-}
-
-func GetRepoOrigin(repoPath string) string {
-	remotes, _ := ExecuteOneLine(repoPath, GIT, "remote", "get-url", ORIGIN)
-	return strings.TrimSpace(remotes)
-}
-
-func FinishRebase(repoPath string) error {
-	// Non porcelain command but there is no way to know if we are mid rebase :(
-	currentStatus, _ := ExecuteOneLine(repoPath, GIT, "status", "-uno")
-	if !strings.Contains(currentStatus, "rebase") {
-		return errors.New("Not in a rebase")
-	}
-
-	if IsUnrealRunning() {
-		return errors.New("Unreal is running, cannot finish rebase")
-	}
-
-	if strings.Contains(currentStatus, "git rebase --continue") ||
-		strings.Contains(currentStatus, "nothing to commit") ||
-		strings.Contains(currentStatus, "all conflicts fixed") {
-
-		// We should be able to continue the rebase
-		_, err := ExecuteOneLine(repoPath, GIT, "rebase", "--continue")
-		return err
-	} else {
-		// We are in a rebase but we have conflicts, this is baaaad
-		return errors.New("You are in the middle of a rebase. Changes on one of your commits will be overridden by incoming changes. Please request help to resolve conflicts.")
-	}
-}
-
-func IsShallowRepo(repoPath string) bool {
-	isShallow, _ := ExecuteOneLine(repoPath, GIT, "rev-parse", "--is-shallow-repository")
-	if strings.Contains(isShallow, "true") {
-		return true
-	}
-	return false
-}
-
-func UnshallowRepo(repoPath string) error {
-	if IsShallowRepo(repoPath) {
-		_, err := ExecuteOneLine(repoPath, GIT, "fetch", "--unshallow")
-		return err
-	}
-	return nil
-}
-
-func GetWorkingTreeFiles(repoPath string, excludeUntracked bool) ([]string, error) {
-	var params []string
-	if excludeUntracked {
-		params = []string{"status", "--porcelain", "--untracked-files=no"}
-	} else {
-		params = []string{"status", "--porcelain"}
-	}
-	lines, err := Execute(repoPath, GIT, params...)
-	if err != nil {
-		return nil, err
-	}
-
-	dedupMap := make(map[string]bool)
-	retval := make([]string, 0)
-	for _, line := range lines {
-		if line != "" {
-			filename := strings.TrimSpace(line[3:])
-			if filename != "" {
-				if strings.Contains(filename, "->") {
-					// This is a rename, we need to add both files
-					split := strings.Split(filename, "->")
-
-					file1 := strings.TrimSpace(split[0])
-					if file1 != "" {
-						_, exists := dedupMap[file1]
-						if !exists {
-							dedupMap[file1] = true
-							retval = append(retval, file1)
-						}
-					}
-
-					file2 := strings.TrimSpace(split[1])
-					if file2 != "" {
-						_, exists := dedupMap[file2]
-						if !exists {
-							dedupMap[file2] = true
-							retval = append(retval, file2)
-						}
-					}
-				} else {
-					_, exists := dedupMap[filename]
-					if !exists {
-						dedupMap[filename] = true
-						retval = append(retval, filename)
-					}
-				}
-			}
-		}
-	}
-
-	return retval, nil
-}
-
-func GetWorkingTreeChangeAmount(repoPath string) int {
-	lines, _ := Execute(repoPath, GIT, "status", "--porcelain")
-	if len(lines) == 0 || lines[0] == "" {
-		return 0
-	}
-
-	//count non empty lines, is there a better way?
-	count := 0
-	for _, line := range lines {
-		if line != "" {
-			count++
-		}
-	}
-
-	return count
-}
-
-func GetAheadBehind(repoPath string) (int, int, error) {
-	statusLines, _ := Execute(repoPath, GIT, "status", "--porcelain=2", "--branch")
-	for _, line := range statusLines {
-		if strings.Contains(line, "# branch.ab ") {
-			trimmed := strings.TrimSpace(strings.Replace(strings.TrimSpace(line), "# branch.ab", "", 1))
-			ab := strings.Split(trimmed, " ")
-			ahead, _ := strconv.Atoi(ab[0])
-			behind, _ := strconv.Atoi(ab[1])
-			return ahead, -behind, nil
-		}
-	}
-	return 0, 0, errors.New("Could not find ahead/behind")
-}
-
-func GitSmartPull(repoPath string) error {
-	ahead, behind, err := GetAheadBehind(repoPath)
-	if err != nil {
-		return err
-	}
-	if behind == 0 {
-		// nothing to pull :)
-		return nil
-	}
-	if ahead == 0 {
-		// we can fast forward, we have no changes!
-		_, err := ExecuteOneLine(repoPath, GIT, "pull", "--ff-only")
-		return err
-	} else {
-		// we have changes, we need to rebase
-		_, err := ExecuteOneLine(repoPath, GIT, "pull", "--rebase", "--autostash")
-		return err
-	}
-}
-
-func GitPush(repoPath string) error {
-	ahead, behind, err := GetAheadBehind(repoPath)
-
-	if err != nil {
-		return err
-	}
-	if ahead == 0 {
-		//nothing to push :)
-		return nil
-	}
-
-	if behind != 0 {
-		// we are behind, we need to pull first
-		return errors.New("Cannot push, you are behind")
-	}
-
-	_, err = ExecuteOneLine(repoPath, GIT, "push")
-	return err
-}
-
-func IsPathRepo(repoPath string) bool {
-	_, err := ExecuteOneLine(repoPath, GIT, "rev-parse", "--git-dir")
-	return err == nil
-}
-
-func IsDeatachedHead(repoPath string) bool {
-	//git symbolic-ref -q HEAD
-	_, err := ExecuteOneLine(repoPath, GIT, "symbolic-ref", "-q", "HEAD")
-	return err != nil
-}
-
-type GitStatus int
-
-const (
-	GIT_STATUS_OK GitStatus = iota
-	GIT_STATUS_SHALLOW
-	GIT_STATUS_DEATACHED_HEAD
-	GIT_STATUS_LAST_COMMIT_MERGE
-	GIT_STATUS_REBASE_CONTINUABLE
-	GIT_STATUS_REBASE_CONFLICTS
-)
-
-func GetGitStatus(repoPath string) GitStatus {
-
-	if IsShallowRepo(repoPath) {
-		return GIT_STATUS_SHALLOW
-	}
-
-	if IsDeatachedHead(repoPath) {
-		return GIT_STATUS_DEATACHED_HEAD
-	}
-
-	ahead, _, _ := GetAheadBehind(repoPath)
-	if ahead > 0 {
-		if IsMergeCommit(repoPath, "") {
-			// This shouldn't have happened! :(
-			return GIT_STATUS_LAST_COMMIT_MERGE
-		}
-	}
-
-	// Non porcelain command but there is no way to know if we are mid rebase :(
-	currentStatus, _ := ExecuteOneLine(repoPath, GIT, "status", "-uno")
-	if strings.Contains(currentStatus, "rebase") {
-		if strings.Contains(currentStatus, "git rebase --continue") ||
-			strings.Contains(currentStatus, "nothing to commit") ||
-			strings.Contains(currentStatus, "all conflicts fixed") {
-
-			// We should be able to continue the rebase
-			return GIT_STATUS_REBASE_CONTINUABLE
-		} else {
-			// We are in a rebase but we have conflicts, this is baaaad
-			return GIT_STATUS_REBASE_CONFLICTS
-		}
-	}
-
-	return GIT_STATUS_OK
-}
-
-func IsMergeCommit(repoPath string, hash string) bool {
-	if hash == "" {
-		hash = "HEAD"
-	}
-	lines, _ := Execute(repoPath, GIT, "cat-file", "-p", hash)
-	countParents := 0
-	if len(lines) < 3 {
-		return false
-	}
-	for i := 0; i < 3; i++ {
-		if strings.HasPrefix(lines[i], "parent ") {
-			countParents++
-		}
-	}
-
-	return countParents > 1
-}
-
-func ReturnToLastBranch(repoPath string) error {
-	_, err := ExecuteOneLine(repoPath, GIT, "switch", "-")
-	return err
-}
-
-func Checkout(repoPath string, hash string) error {
-	_, err := ExecuteOneLine(repoPath, GIT, "checkout", hash)
-	return err
-}
-
-func ResetHard(repoPath string, hash string) error {
-	_, err := ExecuteOneLine(repoPath, GIT, "reset", "--hard", hash)
-	return err
-}
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+type CommitDatum struct {
+	Hash       string
+	Msg        string
+	User       string
+	Date       time.Time
+	Categories []ChangeCategory
+
+	// Only filled when looking at the checked out branch
+	Incoming bool // on the upstream, not pulled yet
+	Outgoing bool // local, not pushed yet
+	IsHead   bool
+}
+
+const SEP = "§"
+
+const COMMIT_PAGE_SIZE = 500
+
+// Every commit starts with a line like this, everything after it until the next one is the changed files
+var COMMIT_LOG_FORMAT = "--format=" + SEP + "%H" + SEP + "%an" + SEP + "%at" + SEP + "%s"
+
+type CommitLogParser struct {
+	current  *CommitDatum
+	OnCommit func(*CommitDatum)
+	// Optional, without it the changed files are ignored
+	Classifier *FileClassifier
+}
+
+func (parser *CommitLogParser) ParseLine(line string) error {
+	if strings.HasPrefix(line, SEP) {
+		parser.Flush()
+
+		fields := strings.SplitN(line, SEP, 5)
+		if len(fields) != 5 {
+			return fmt.Errorf("Malformed commit line: %s", line)
+		}
+		timestamp, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Malformed commit date: %s", line)
+		}
+
+		parser.current = &CommitDatum{
+			Hash: fields[1],
+			User: fields[2],
+			Date: time.Unix(timestamp, 0),
+			Msg:  fields[4],
+		}
+		return nil
+	}
+
+	file := strings.TrimSpace(line)
+	if file == "" || parser.current == nil || parser.Classifier == nil {
+		return nil
+	}
+	parser.Classifier.ClassifyFile(parser.current, file)
+
+	return nil
+}
+
+// Hands over the commit being parsed, call it once there are no more lines.
+func (parser *CommitLogParser) Flush() {
+	if parser.current != nil {
+		parser.OnCommit(parser.current)
+		parser.current = nil
+	}
+}
+
+func readCommitLog(repo *Repo, stdin io.Reader, classifier *FileClassifier, onCommit func(*CommitDatum), params ...string) error {
+	params = append([]string{"-c", "core.quotePath=false", "log", "--no-color", COMMIT_LOG_FORMAT}, params...)
+
+	parser := &CommitLogParser{OnCommit: onCommit, Classifier: classifier}
+	err := repo.executeStream(stdin, parser.ParseLine, params...)
+	if err != nil {
+		return err
+	}
+	parser.Flush()
+
+	return nil
+}
+
+// Classifies the commits not found in the cache with a single git call, then stores them in it.
+// Merges get what they brought into their first parent, without -m --first-parent git lists no files for them.
+func classifyCommits(repo *Repo, commits []*CommitDatum) error {
+	classifier, err := repo.Config.ClassificationRules.Compile()
+	if err != nil {
+		return err
+	}
+
+	cache := LoadCommitCache(repo)
+
+	pending := make(map[string]*CommitDatum)
+	var missing strings.Builder
+	for _, commit := range commits {
+		if !cache.Apply(commit) {
+			pending[commit.Hash] = commit
+			missing.WriteString(commit.Hash + "\n")
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err = readCommitLog(repo, strings.NewReader(missing.String()), classifier, func(classified *CommitDatum) {
+		commit, ok := pending[classified.Hash]
+		if !ok {
+			return
+		}
+		commit.Categories = classified.Categories
+		cache.Store(commit)
+	}, "--name-only", "--no-walk=unsorted", "-m", "--first-parent", "--stdin")
+	if err != nil {
+		return err
+	}
+
+	return cache.Save()
+}
+
+// An empty branchName means HEAD. limit <= 0 means the whole history.
+// When looking at HEAD the upstream commits not pulled yet are included too.
+func GetRepoBranchInfo(repo *Repo, branchName string, offset int, limit int) ([]*CommitDatum, error) {
+	start := time.Now()
+
+	params := make([]string, 0)
+	if offset > 0 {
+		params = append(params, "--skip="+strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		params = append(params, "--max-count="+strconv.Itoa(limit))
+	}
+	if branchName == "" {
+		branchName = "HEAD"
+	}
+	includeUpstream := branchName == "HEAD" && HasUpstream(repo)
+	params = append(params, branchName)
+	if includeUpstream {
+		params = append(params, "@{upstream}")
+	}
+	params = append(params, "--")
+
+	commitData := make([]*CommitDatum, 0)
+	err := readCommitLog(repo, nil, nil, func(commit *CommitDatum) {
+		commitData = append(commitData, commit)
+	}, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = classifyCommits(repo, commitData)
+	if err != nil {
+		return nil, err
+	}
+
+	err = markSyncState(repo, commitData, includeUpstream)
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := time.Since(start)
+	log.Printf("Git took %s", elapsed)
+
+	return commitData, nil
+}
+
+func markSyncState(repo *Repo, commits []*CommitDatum, includeUpstream bool) error {
+	head, err := repo.executeOneLine("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	head = strings.TrimSpace(head)
+
+	incoming := make(map[string]bool)
+	outgoing := make(map[string]bool)
+	if includeUpstream {
+		// <hash is only in HEAD, >hash is only in the upstream
+		lines, err := repo.execute("rev-list", "--left-right", "HEAD...@{upstream}", "--")
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "<") {
+				outgoing[line[1:]] = true
+			} else if strings.HasPrefix(line, ">") {
+				incoming[line[1:]] = true
+			}
+		}
+	}
+
+	for _, commit := range commits {
+		commit.IsHead = commit.Hash == head
+		commit.Incoming = incoming[commit.Hash]
+		commit.Outgoing = outgoing[commit.Hash]
+	}
+
+	return nil
+}
+
+func HasUpstream(repo *Repo) bool {
+	_, err := repo.executeOneLine("rev-parse", "--abbrev-ref", "@{upstream}")
+	return err == nil
+}
+
+// Doesn't take the repo lock, fetching only touches the remote branches so it can run next to anything else
+func GitFetch(repo *Repo) error {
+	_, err := repo.executeProgress("fetch", repo.Config.RemoteName)
+	return err
+}
+
+// Local branches first (main branch on top), then remote ones (origin/main and such)
+func GetBranches(repo *Repo) ([]string, error) {
+	lines, err := repo.execute("for-each-ref", "--format=%(refname)"+SEP+"%(symref)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+
+	local := make([]string, 0)
+	remote := make([]string, 0)
+	for _, line := range lines {
+		fields := strings.SplitN(strings.TrimSpace(line), SEP, 2)
+		if len(fields) != 2 || fields[0] == "" {
+			continue
+		}
+		if fields[1] != "" {
+			// symbolic refs like origin/HEAD just point to another branch
+			continue
+		}
+		if fields[0] == "refs/heads/"+repo.Config.MainBranch {
+			local = append([]string{repo.Config.MainBranch}, local...)
+		} else if strings.HasPrefix(fields[0], "refs/heads/") {
+			local = append(local, strings.TrimPrefix(fields[0], "refs/heads/"))
+		} else {
+			remote = append(remote, strings.TrimPrefix(fields[0], "refs/remotes/"))
+		}
+	}
+
+	return append(local, remote...), nil
+}
+
+func GetCurrentBranchFromRepository(repo *Repo) (string, error) {
+
+	repository, err := git.PlainOpen(repo.Path)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return "", fmt.Errorf("%w: %s", ErrNotARepo, repo.Path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	branchRefs, err := repository.Branches()
+	if err != nil {
+		return "", err
+	}
+
+	headRef, err := repository.Head()
+	if err != nil {
+		return "", err
+	}
+
+	var currentBranchName string
+	err = branchRefs.ForEach(func(branchRef *plumbing.Reference) error {
+		if branchRef.Hash() == headRef.Hash() {
+			currentBranchName = branchRef.Name().String()
+
+			return nil
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return currentBranchName, nil
+}
+
+func LinkGitConfig(repo *Repo) error {
+	repo, unlock, err := repo.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !FileExists(filepath.Join(repo.Path, ".gitconfig")) {
+		return errors.New(".gitconfig file missing!")
+	}
+
+	_, err = repo.execute("config", "--local", "include.path", ".gitconfig")
+	return err
+}
+
+func CreateGitConfig(repo *Repo) error {
+	repo, unlock, err := repo.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if FileExists(filepath.Join(repo.Path, ".gitconfig")) {
+		return errors.New(".gitconfig already exists!")
+	}
+
+	f, err := os.Create(filepath.Join(repo.Path, ".gitconfig"))
+
+	if err != nil {
+		return err
+	}
+
+	_, err2 := f.WriteString(GetConfigString(repo.Config.RemoteName))
+
+	if err2 != nil {
+		return err2
+	}
+
+	f.Close()
+
+	LinkGitConfig(repo)
+
+	return nil
+}
+
+type GitConfigStatus int
+
+const (
+	CONFIG_STATUS_MISSING GitConfigStatus = iota
+	CONFIG_STATUS_NOT_LINKED
+	CONFIG_STATUS_LINKED
+	// git couldn't tell, comes with an error
+	CONFIG_STATUS_UNKNOWN
+)
+
+func (status GitConfigStatus) String() string {
+	switch status {
+	case CONFIG_STATUS_MISSING:
+		return "missing"
+	case CONFIG_STATUS_NOT_LINKED:
+		return "not-linked"
+	case CONFIG_STATUS_LINKED:
+		return "linked"
+	}
+	return "unknown"
+}
+
+func GetGitConfigStatus(repo *Repo) (GitConfigStatus, error) {
+	exists := FileExists(filepath.Join(repo.Path, ".gitconfig"))
+	if !exists {
+		return CONFIG_STATUS_MISSING, nil
+	}
+	includePath, err := getLocalConfig(repo, "include.path")
+	if err != nil {
+		return CONFIG_STATUS_UNKNOWN, err
+	}
+	if includePath == "" {
+		return CONFIG_STATUS_NOT_LINKED, nil
+	}
+
+	return CONFIG_STATUS_LINKED, nil
+}
+
+// Reads a key from the repo config, empty when it isn't set
+func getLocalConfig(repo *Repo, key string) (string, error) {
+	value, err := repo.executeStdout("config", "--local", key)
+	var execErr ErrExec
+	if errors.As(err, &execErr) && execErr.ExitCode == 1 {
+		// git config says 1 for a missing key, anything else is a real failure
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func NeedsUsernameFix(repo *Repo) (bool, error) {
+	username, err := GetUsernameFromRepo(repo)
+	if err != nil {
+		return false, err
+	}
+	email, err := GetUserEmailFromRepo(repo)
+	if err != nil {
+		return false, err
+	}
+	return username == "" || email == "", nil
+}
+
+func GetUsernameFromRepo(repo *Repo) (string, error) {
+	return getLocalConfig(repo, "user.name")
+}
+
+func GetUserEmailFromRepo(repo *Repo) (string, error) {
+	return getLocalConfig(repo, "user.email")
+}
+
+func SetUsernameAndEmail(repo *Repo, username string, email string) error {
+	repo, unlock, err := repo.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = repo.executeOneLine("config", "--local", "user.name", username)
+	if err != nil {
+		return err
+	}
+	_, err2 := repo.executeOneLine("config", "--local", "user.email", email)
+	if err2 != nil {
+		return err2
+	}
+	return nil
+}
+
+func GetGitProviderName(repo *Repo) (string, error) {
+	// Probably fails if you have many remotes
+
+	remotes, err := GetRepoOrigin(repo)
+	if err != nil {
+		return "Unknown", err
+	}
+
+	if strings.Contains(remotes, "github") {
+		return "GitHub", nil
+	}
+	if strings.Contains(remotes, "gitlab") {
+		return "GitLab", nil
+	}
+	if strings.Contains(remotes, "gitea") {
+		return "Gitea", nil // probably not correct, but what can I do?
+	}
+
+	return "Unknown", nil
+}
+
+func GetRepoOrigin(repo *Repo) (string, error) {
+	remotes, err := repo.executeStdout("remote", "get-url", repo.Config.RemoteName)
+	if err != nil {
+		return "", fmt.Errorf("Could not read the url of the remote %s: %w", repo.Config.RemoteName, err)
+	}
+	return strings.TrimSpace(remotes), nil
+}
+
+func FinishRebase(repo *Repo) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	operation, err := GetOngoingOperation(repo)
+	if err != nil {
+		return err
+	}
+	if operation != GIT_OPERATION_REBASE {
+		return errors.New("Not in a rebase")
+	}
+
+	if IsUnrealRunning() {
+		return errors.New("Unreal is running, cannot finish rebase")
+	}
+
+	snapshot, err := GetRepoSnapshot(repo, false)
+	if err != nil {
+		return err
+	}
+	if len(snapshot.ConflictedFiles()) > 0 {
+		// We are in a rebase but we have conflicts, this is baaaad
+		return errors.New("You are in the middle of a rebase. Changes on one of your commits will be overridden by incoming changes. Please request help to resolve conflicts.")
+	}
+
+	// keep the commit messages as they are, there is no one to type a new one
+	_, err = repo.executeOneLine("-c", "core.editor=true", "rebase", "--continue")
+	return err
+}
+
+func IsShallowRepo(repo *Repo) (bool, error) {
+	isShallow, err := repo.executeStdout("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(isShallow) == "true", nil
+}
+
+func UnshallowRepo(repo *Repo) error {
+	repo, unlock, err := repo.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	shallow, err := IsShallowRepo(repo)
+	if err != nil {
+		return err
+	}
+	if shallow {
+		_, err := repo.executeProgress("fetch", "--unshallow")
+		return err
+	}
+	return nil
+}
+
+func GetWorkingTreeFiles(repo *Repo, excludeUntracked bool) ([]string, error) {
+	snapshot, err := GetRepoSnapshot(repo, !excludeUntracked)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.FilePaths(excludeUntracked), nil
+}
+
+func GetWorkingTreeChangeAmount(repo *Repo) (int, error) {
+	snapshot, err := GetRepoSnapshot(repo, true)
+	if err != nil {
+		return 0, err
+	}
+	return snapshot.ChangeAmount(), nil
+}
+
+func GetAheadBehind(repo *Repo) (int, int, error) {
+	snapshot, err := GetRepoSnapshot(repo, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	return snapshot.AheadBehind()
+}
+
+func GitSmartPull(repo *Repo) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ahead, behind, err := GetAheadBehind(repo)
+	if err != nil {
+		return err
+	}
+	if behind == 0 {
+		// nothing to pull :)
+		return nil
+	}
+	if ahead == 0 {
+		// we can fast forward, we have no changes!
+		_, err := repo.executeProgress("pull", "--ff-only")
+		return err
+	} else {
+		// we have changes, we need to rebase
+		_, err := CreateBackup(repo, BACKUP_OPERATION_PULL)
+		if err != nil {
+			return err
+		}
+		_, err = repo.executeProgress("pull", "--rebase", "--autostash")
+		return err
+	}
+}
+
+var ErrPushBehind = errors.New("Cannot push, you are behind")
+
+func GitPush(repo *Repo) error {
+	repo, unlock, err := repo.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ahead, behind, err := GetAheadBehind(repo)
+
+	if err != nil {
+		return err
+	}
+	if ahead == 0 {
+		//nothing to push :)
+		return nil
+	}
+
+	if behind != 0 {
+		// we are behind, we need to pull first
+		return ErrPushBehind
+	}
+
+	_, err = repo.executeProgress("push", repo.Config.RemoteName)
+	if err != nil {
+		return err
+	}
+
+	if repo.Config.UnlockAfterPush {
+		unlockErrors := UnlockOwnUnchangedFiles(repo)
+		if unlockErrors != nil {
+			return fmt.Errorf("Pushed, but %d files couldn't be unlocked: %w", len(unlockErrors), errors.Join(unlockErrors...))
+		}
+	}
+
+	return nil
+}
+
+func IsPathRepo(repo *Repo) bool {
+	return CheckRepo(repo) == nil
+}
+
+// Nil if git works and the path is a repository, ErrGitMissing or ErrNotARepo otherwise
+func CheckRepo(repo *Repo) error {
+	info, err := os.Stat(repo.Path)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrNotARepo, repo.Path)
+	}
+	_, err = repo.executeOneLine("rev-parse", "--git-dir")
+	return err
+}
+
+func IsDeatachedHead(repo *Repo) (bool, error) {
+	_, err := repo.executeOneLine("symbolic-ref", "-q", "HEAD")
+	var execErr ErrExec
+	if errors.As(err, &execErr) && execErr.ExitCode == 1 {
+		// -q makes it exit with 1 and say nothing when HEAD is not a branch
+		return true, nil
+	}
+	return false, err
+}
+
+type GitStatus int
+
+const (
+	GIT_STATUS_OK GitStatus = iota
+	GIT_STATUS_SHALLOW
+	GIT_STATUS_DEATACHED_HEAD
+	GIT_STATUS_LAST_COMMIT_MERGE
+	GIT_STATUS_REBASE_CONTINUABLE
+	GIT_STATUS_REBASE_CONFLICTS
+	GIT_STATUS_MERGE_IN_PROGRESS
+	GIT_STATUS_CHERRY_PICK_IN_PROGRESS
+	GIT_STATUS_BISECT_IN_PROGRESS
+	GIT_STATUS_STALE_INDEX_LOCK
+	// git couldn't tell, comes with an error
+	GIT_STATUS_UNKNOWN
+)
+
+func (status GitStatus) String() string {
+	switch status {
+	case GIT_STATUS_OK:
+		return "ok"
+	case GIT_STATUS_SHALLOW:
+		return "shallow"
+	case GIT_STATUS_DEATACHED_HEAD:
+		return "detached-head"
+	case GIT_STATUS_LAST_COMMIT_MERGE:
+		return "last-commit-merge"
+	case GIT_STATUS_REBASE_CONTINUABLE:
+		return "rebase-continuable"
+	case GIT_STATUS_REBASE_CONFLICTS:
+		return "rebase-conflicts"
+	case GIT_STATUS_MERGE_IN_PROGRESS:
+		return "merge-in-progress"
+	case GIT_STATUS_CHERRY_PICK_IN_PROGRESS:
+		return "cherry-pick-in-progress"
+	case GIT_STATUS_BISECT_IN_PROGRESS:
+		return "bisect-in-progress"
+	case GIT_STATUS_STALE_INDEX_LOCK:
+		return "stale-index-lock"
+	}
+	return "unknown"
+}
+
+func GetGitStatus(repo *Repo) (GitStatus, error) {
+	snapshot, err := GetRepoSnapshot(repo, false)
+	if err != nil {
+		return GIT_STATUS_UNKNOWN, err
+	}
+	return GetGitStatusFromSnapshot(repo, snapshot)
+}
+
+// Same as GetGitStatus for when the caller already has a snapshot
+func GetGitStatusFromSnapshot(repo *Repo, snapshot *RepoSnapshot) (GitStatus, error) {
+
+	staleLock, err := HasStaleIndexLock(repo)
+	if err != nil {
+		return GIT_STATUS_UNKNOWN, err
+	}
+	if staleLock {
+		// nothing that changes the repo works until it is gone
+		return GIT_STATUS_STALE_INDEX_LOCK, nil
+	}
+
+	shallow, err := IsShallowRepo(repo)
+	if err != nil {
+		return GIT_STATUS_UNKNOWN, err
+	}
+	if shallow {
+		return GIT_STATUS_SHALLOW, nil
+	}
+
+	// these leave HEAD deatached or half merged, so they go first
+	operation, err := GetOngoingOperation(repo)
+	if err != nil {
+		return GIT_STATUS_UNKNOWN, err
+	}
+	switch operation {
+	case GIT_OPERATION_REBASE:
+		if len(snapshot.ConflictedFiles()) > 0 {
+			// We are in a rebase but we have conflicts, this is baaaad
+			return GIT_STATUS_REBASE_CONFLICTS, nil
+		}
+		// We should be able to continue the rebase
+		return GIT_STATUS_REBASE_CONTINUABLE, nil
+	case GIT_OPERATION_MERGE:
+		return GIT_STATUS_MERGE_IN_PROGRESS, nil
+	case GIT_OPERATION_CHERRY_PICK:
+		return GIT_STATUS_CHERRY_PICK_IN_PROGRESS, nil
+	case GIT_OPERATION_BISECT:
+		return GIT_STATUS_BISECT_IN_PROGRESS, nil
+	}
+
+	if snapshot.DeatachedHead {
+		return GIT_STATUS_DEATACHED_HEAD, nil
+	}
+
+	if snapshot.Ahead > 0 {
+		merges, err := HasUnpushedMerges(repo)
+		if err != nil {
+			return GIT_STATUS_UNKNOWN, err
+		}
+		if merges {
+			// This shouldn't have happened! :(
+			return GIT_STATUS_LAST_COMMIT_MERGE, nil
+		}
+	}
+
+	return GIT_STATUS_OK, nil
+}
+
+func IsMergeCommit(repo *Repo, hash string) (bool, error) {
+	if hash == "" {
+		hash = "HEAD"
+	}
+	lines, err := repo.execute("cat-file", "-p", hash)
+	if err != nil {
+		return false, err
+	}
+	countParents := 0
+	if len(lines) < 3 {
+		return false, nil
+	}
+	for i := 0; i < 3; i++ {
+		if strings.HasPrefix(lines[i], "parent ") {
+			countParents++
+		}
+	}
+
+	return countParents > 1, nil
+}
+
+// A merge anywhere in what we haven't pushed, not only on top
+func HasUnpushedMerges(repo *Repo) (bool, error) {
+	if !HasUpstream(repo) {
+		// nothing to compare with, the last commit is all we can look at
+		return IsMergeCommit(repo, "")
+	}
+	count, err := repo.executeStdout("rev-list", "--merges", "--count", "@{upstream}..HEAD", "--")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(count) != "0", nil
+}
+
+func ReturnToLastBranch(repo *Repo) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = CreateBackup(repo, BACKUP_OPERATION_CHECKOUT)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.executeProgress("switch", "-")
+	if err != nil && repo.Config.MainBranch != "" {
+		// there might be no previous branch, main is the next best thing
+		_, err = repo.executeProgress("switch", repo.Config.MainBranch)
+	}
+	return err
+}
+
+func Checkout(repo *Repo, hash string) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = CreateBackup(repo, BACKUP_OPERATION_CHECKOUT)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.executeProgress("checkout", hash)
+	return err
+}
+
+func ResetHard(repo *Repo, hash string) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = CreateBackup(repo, BACKUP_OPERATION_RESET)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.executeProgress("reset", "--hard", hash)
+	return err
+}
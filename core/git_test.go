@@ -0,0 +1,38 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+// testdata/commits_merge.json: "Feature code" (Source) and "Feature art" (Content) on a branch merged with --no-ff
+// into main after "Plugin", everything pushed
+
+func TestGetRepoBranchInfoClassifiesMerges(t *testing.T) {
+	repo, fake := replayRepo(t, "commits_merge.json")
+
+	commits, err := GetRepoBranchInfo(repo, "HEAD", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]ChangeCategory{
+		// what the branch brought in
+		"Merge feature": {CATEGORY_CONTENT, CATEGORY_SOURCE},
+		"Plugin":        {CATEGORY_PLUGINS},
+		"Feature art":   {CATEGORY_CONTENT},
+		"First":         {CATEGORY_CONFIG},
+		"Feature code":  {CATEGORY_SOURCE},
+	}
+	if len(commits) != len(want) {
+		t.Fatalf("got %d commits, want %d", len(commits), len(want))
+	}
+	for _, commit := range commits {
+		if !slices.Equal(commit.Categories, want[commit.Msg]) {
+			t.Errorf("%s: got %v, want %v", commit.Msg, commit.Categories, want[commit.Msg])
+		}
+	}
+	if !commits[0].IsHead {
+		t.Error("the merge should be HEAD")
+	}
+	assertAllReplayed(t, fake)
+}
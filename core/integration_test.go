@@ -0,0 +1,458 @@
+package core_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/miltoncandelero/ugsg/core"
+	"github.com/miltoncandelero/ugsg/internal/gittest"
+)
+
+// End to end against a local bare remote, see internal/gittest. go test -short skips them.
+
+func assertStatus(t *testing.T, wc *gittest.WorkingCopy, want core.GitStatus) {
+	t.Helper()
+	status, err := core.GetGitStatus(wc.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != want {
+		t.Errorf("%s: got status %s, want %s", wc.User, status, want)
+	}
+}
+
+func TestPullFastForward(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"Config/DefaultGame.ini": "[Game]\n"})
+	alice.Push()
+
+	bob := remote.Clone("bob")
+	want := alice.Commit("Second", map[string]string{"Source/Game.cpp": "int main() {}\n"})
+	alice.Push()
+
+	err := core.GitFetch(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.GitSmartPull(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bob.Head() != want {
+		t.Errorf("bob is on %s, want %s", bob.Head(), want)
+	}
+	backups, err := core.GetBackups(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 0 {
+		t.Errorf("a fast forward shouldn't need a backup, got %d", len(backups))
+	}
+	assertStatus(t, bob, core.GIT_STATUS_OK)
+}
+
+func TestPullRebasesLocalCommits(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"README.md": "hi\n"})
+	alice.Push()
+
+	bob := remote.Clone("bob")
+	bobHead := bob.Commit("Bob's work", map[string]string{"Source/Bob.cpp": "bob\n"})
+	// uncommited changes get stashed and come back
+	bob.WriteFile("README.md", "hi from bob\n")
+
+	aliceHead := alice.Commit("Alice's work", map[string]string{"Source/Alice.cpp": "alice\n"})
+	alice.Push()
+
+	err := core.GitFetch(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.GitSmartPull(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ahead, behind, err := core.GetAheadBehind(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ahead != 1 || behind != 0 {
+		t.Errorf("got ahead %d behind %d, want 1 and 0", ahead, behind)
+	}
+	if bob.Git("rev-parse", "HEAD~1") != aliceHead {
+		t.Error("bob's commit should be on top of alice's")
+	}
+	if bob.ReadFile("README.md") != "hi from bob\n" {
+		t.Error("the uncommited change was lost")
+	}
+
+	backups, err := core.GetBackups(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Hash != bobHead || backups[0].Operation != core.BACKUP_OPERATION_PULL {
+		t.Errorf("expected a pull backup at %s, got %+v", bobHead, backups)
+	}
+}
+
+func TestSync(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"README.md": "hi\n"})
+	alice.Push()
+
+	bob := remote.Clone("bob")
+	alice.Commit("Alice's work", map[string]string{"Source/Alice.cpp": "alice\n"})
+	alice.Push()
+	bob.Commit("Bob's work", map[string]string{"Source/Bob.cpp": "bob\n"})
+
+	err := core.GitFetch(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// behind first, so the push is refused
+	err = core.GitPush(bob.Repo)
+	if !errors.Is(err, core.ErrPushBehind) {
+		t.Fatalf("got %v, want ErrPushBehind", err)
+	}
+
+	journal, err := core.StartJournal(bob.Repo, "sync", core.PullStep(), core.PushStep())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.RunJournal(bob.Repo, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if remote.Head() != bob.Head() {
+		t.Errorf("remote is on %s, bob on %s", remote.Head(), bob.Head())
+	}
+	pending, err := core.LoadJournal(bob.Repo)
+	if err != nil || pending != nil {
+		t.Errorf("the journal should be finished, got %+v, %v", pending, err)
+	}
+	assertStatus(t, bob, core.GIT_STATUS_OK)
+}
+
+func TestTimeTravel(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	first := alice.Commit("First", map[string]string{"Content/Map.umap": "v1"})
+	second := alice.Commit("Second", map[string]string{"Content/Map.umap": "v2"})
+	third := alice.Commit("Third", map[string]string{"Content/Map.umap": "v3"})
+	alice.Push()
+
+	// checkout leaves HEAD deatached, going back puts us on main again
+	err := core.Checkout(alice.Repo, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.ReadFile("Content/Map.umap") != "v2" {
+		t.Error("checkout didn't bring the old file back")
+	}
+	assertStatus(t, alice, core.GIT_STATUS_DEATACHED_HEAD)
+
+	err = core.ReturnToLastBranch(alice.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Head() != third {
+		t.Errorf("back on %s, want %s", alice.Head(), third)
+	}
+	assertStatus(t, alice, core.GIT_STATUS_OK)
+
+	// reset moves main itself, the backup undoes it
+	journal, err := core.StartJournal(alice.Repo, "time travel", core.ResetStep(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.RunJournal(alice.Repo, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Head() != first || alice.ReadFile("Content/Map.umap") != "v1" {
+		t.Errorf("reset left HEAD on %s", alice.Head())
+	}
+	_, behind, err := core.GetAheadBehind(alice.Repo)
+	if err != nil || behind != 2 {
+		t.Errorf("got behind %d (%v), want 2", behind, err)
+	}
+
+	backups, err := core.GetBackups(alice.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := slices.IndexFunc(backups, func(backup core.BackupDatum) bool { return backup.Operation == core.BACKUP_OPERATION_RESET })
+	if idx == -1 {
+		t.Fatalf("no reset backup in %+v", backups)
+	}
+	err = core.RestoreBackup(alice.Repo, backups[idx])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Head() != third || alice.Git("branch", "--show-current") != gittest.MAIN_BRANCH {
+		t.Errorf("restore left HEAD on %s", alice.Head())
+	}
+}
+
+func TestLFSPushAndPull(t *testing.T) {
+	remote := gittest.NewLFSRemote(t)
+	alice := remote.Clone("alice")
+	alice.TrackLFS("*.uasset", true)
+	alice.Commit("Add a mesh", map[string]string{"Content/Mesh.uasset": "a very large mesh"})
+	// the pre-push hook uploads it
+	alice.Push()
+	if remote.LFS.ObjectCount() != 1 {
+		t.Fatalf("the server has %d objects, want 1", remote.LFS.ObjectCount())
+	}
+	// the git remote only has the pointer
+	pointer := alice.Git("--git-dir="+remote.Path, "show", gittest.MAIN_BRANCH+":Content/Mesh.uasset")
+	if pointer == "a very large mesh" {
+		t.Error("the file went to git instead of LFS")
+	}
+
+	bob := remote.Clone("bob")
+	if bob.ReadFile("Content/Mesh.uasset") != "a very large mesh" {
+		t.Error("the clone didn't download the LFS file")
+	}
+	alice.Commit("Change the mesh", map[string]string{"Content/Mesh.uasset": "an even larger mesh"})
+	err := core.GitPush(alice.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = core.GitFetch(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.GitSmartPull(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.ReadFile("Content/Mesh.uasset") != "an even larger mesh" {
+		t.Error("the pull didn't download the new LFS file")
+	}
+	if remote.LFS.ObjectCount() != 2 {
+		t.Errorf("the server has %d objects, want 2", remote.LFS.ObjectCount())
+	}
+
+	lockable, err := core.GetLockableFiles(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(lockable, []string{"Content/Mesh.uasset"}) {
+		t.Errorf("got lockable files %q", lockable)
+	}
+}
+
+func TestLFSLocks(t *testing.T) {
+	remote := gittest.NewLFSRemote(t)
+	alice := remote.Clone("alice")
+	alice.TrackLFS("*.uasset", true)
+	alice.Commit("Add assets", map[string]string{
+		"Content/Hero.uasset":    "hero",
+		"Content/Villain.uasset": "villain",
+		"Content/Prop.uasset":    "prop",
+	})
+	alice.Push()
+	bob := remote.Clone("bob")
+
+	alice.Git("lfs", "lock", "Content/Hero.uasset")
+	alice.Git("lfs", "lock", "Content/Villain.uasset")
+	carolsLock := remote.LFS.Lock("carol", "Content/Prop.uasset")
+
+	all, err := core.GetLockedFiles(bob.Repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("got %d locks, want 3: %+v", len(all), all)
+	}
+	mine, err := core.GetLockedFiles(alice.Repo, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mine) != 2 {
+		t.Errorf("got %d of alice's locks, want 2: %+v", len(mine), mine)
+	}
+
+	// bob can't commit a lockable file he didn't lock
+	bob.WriteFile("Content/Hero.uasset", "bob's hero")
+	missing, err := core.GetFilesMissingLock(bob.Repo, []string{"Content/Hero.uasset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(missing, []string{"Content/Hero.uasset"}) {
+		t.Errorf("got files missing lock %q", missing)
+	}
+	bob.Git("checkout", "--", "Content/Hero.uasset")
+
+	// somebody else's lock only goes with force
+	unlockErrors := core.UnlockLFSFiles(bob.Repo, []core.LockDatum{carolsLock}, false)
+	if len(unlockErrors) != 1 {
+		t.Errorf("unlocking carol's file without force should fail, got %v", unlockErrors)
+	}
+	unlockErrors = core.UnlockLFSFiles(bob.Repo, []core.LockDatum{carolsLock}, true)
+	if unlockErrors != nil {
+		t.Fatal(errors.Join(unlockErrors...))
+	}
+
+	// alice keeps the lock of the file she is working on
+	alice.WriteFile("Content/Villain.uasset", "scarier villain")
+	unlockErrors = core.UnlockOwnUnchangedFiles(alice.Repo)
+	if unlockErrors != nil {
+		t.Fatal(errors.Join(unlockErrors...))
+	}
+	locks := remote.LFS.Locks()
+	if len(locks) != 1 || locks[0].Path != "Content/Villain.uasset" || locks[0].Owner.Name != "alice" {
+		t.Errorf("only alice's lock on the changed file should be left, got %+v", locks)
+	}
+}
+
+func TestFailedUnlockKeepsOnlyTheLocksLeft(t *testing.T) {
+	remote := gittest.NewLFSRemote(t)
+	alice := remote.Clone("alice")
+	alice.TrackLFS("*.uasset", true)
+	alice.Commit("Add assets", map[string]string{
+		"Content/Hero.uasset":    "hero",
+		"Content/Villain.uasset": "villain",
+		"Content/Prop.uasset":    "prop",
+	})
+	alice.Push()
+
+	hero := remote.LFS.Lock("alice", "Content/Hero.uasset")
+	carolsLock := remote.LFS.Lock("carol", "Content/Prop.uasset")
+	villain := remote.LFS.Lock("alice", "Content/Villain.uasset")
+
+	journal, err := core.StartJournal(alice.Repo, "unlock", core.UnlockStep([]core.LockDatum{hero, carolsLock, villain}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.RunJournal(alice.Repo, journal)
+	if err == nil {
+		t.Fatal("unlocking carol's file without force should fail")
+	}
+
+	pending, err := core.LoadJournal(alice.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := pending.PendingSteps()
+	if len(steps) != 1 || !slices.Equal(steps[0].Args, []string{"no-force", carolsLock.ID}) {
+		t.Fatalf("only carol's lock should be left to unlock, got %+v", steps)
+	}
+
+	// resuming doesn't trip over the locks already gone
+	err = core.RunJournal(alice.Repo, pending)
+	if err == nil || !strings.HasPrefix(err.Error(), "1 files couldn't be unlocked") {
+		t.Errorf("got %v, want only carol's lock failing", err)
+	}
+	locks := remote.LFS.Locks()
+	if len(locks) != 1 || locks[0].ID != carolsLock.ID {
+		t.Errorf("only carol's lock should be left, got %+v", locks)
+	}
+}
+
+func TestFailedSyncKeepsJournalAndRollsBackWithChanges(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"README.md": "hi\n"})
+	alice.Push()
+
+	bob := remote.Clone("bob")
+	bobHead := bob.Commit("Bob's work", map[string]string{"Source/Bob.cpp": "bob\n"})
+	bob.WriteFile("README.md", "hi from bob\n")
+	alice.Commit("Alice's work", map[string]string{"Source/Alice.cpp": "alice\n"})
+	alice.Push()
+	remote.RejectPushes()
+
+	err := core.GitFetch(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := core.StartJournal(bob.Repo, "sync", core.PullStep(), core.PushStep())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.RunJournal(bob.Repo, journal)
+	if err == nil {
+		t.Fatal("the push should have been rejected")
+	}
+
+	// the pull went through, the push didn't
+	pending, err := core.LoadJournal(bob.Repo)
+	if err != nil || pending == nil {
+		t.Fatalf("the journal should still be there, got %+v, %v", pending, err)
+	}
+	if steps := pending.PendingSteps(); len(steps) != 1 || steps[0].Name != core.JOURNAL_STEP_PUSH {
+		t.Errorf("only the push should be left, got %+v", steps)
+	}
+
+	err = core.RollbackJournal(bob.Repo, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Head() != bobHead {
+		t.Errorf("rolled back to %s, want %s", bob.Head(), bobHead)
+	}
+	if bob.ReadFile("README.md") != "hi from bob\n" {
+		t.Error("the uncommited change was lost")
+	}
+	if bob.Git("stash", "list") != "" {
+		t.Error("the stash should be empty again")
+	}
+	pending, err = core.LoadJournal(bob.Repo)
+	if err != nil || pending != nil {
+		t.Errorf("the journal should be finished, got %+v, %v", pending, err)
+	}
+}
+
+func TestConflictedPullRollsBackWithChanges(t *testing.T) {
+	remote := gittest.NewRemote(t)
+	alice := remote.Clone("alice")
+	alice.Commit("First", map[string]string{"Config/DefaultGame.ini": "[Game]\n", "README.md": "hi\n"})
+	alice.Push()
+
+	bob := remote.Clone("bob")
+	bobHead := bob.Commit("Bob's settings", map[string]string{"Config/DefaultGame.ini": "[Game]\nBob=1\n"})
+	bob.WriteFile("README.md", "hi from bob\n")
+	alice.Commit("Alice's settings", map[string]string{"Config/DefaultGame.ini": "[Game]\nAlice=1\n"})
+	alice.Push()
+
+	err := core.GitFetch(bob.Repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := core.StartJournal(bob.Repo, "pull", core.PullStep())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.RunJournal(bob.Repo, journal)
+	if err == nil {
+		t.Fatal("the rebase should have stopped on the conflict")
+	}
+	assertStatus(t, bob, core.GIT_STATUS_REBASE_CONFLICTS)
+
+	pending, err := core.LoadJournal(bob.Repo)
+	if err != nil || pending == nil {
+		t.Fatalf("the journal should still be there, got %+v, %v", pending, err)
+	}
+	err = core.RollbackJournal(bob.Repo, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Head() != bobHead {
+		t.Errorf("rolled back to %s, want %s", bob.Head(), bobHead)
+	}
+	if bob.ReadFile("README.md") != "hi from bob\n" {
+		t.Error("the uncommited change was lost")
+	}
+	assertStatus(t, bob, core.GIT_STATUS_OK)
+}
@@ -0,0 +1,333 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Multi step operations write down what they did here, so if the app dies halfway
+// we can tell the user on the next start and resume or roll back.
+const JOURNAL_FILE = "ugsg/journal.json"
+
+const (
+	JOURNAL_STEP_PULL     = "pull"
+	JOURNAL_STEP_PUSH     = "push"
+	JOURNAL_STEP_UNLOCK   = "unlock"
+	JOURNAL_STEP_RESET    = "reset"
+	JOURNAL_STEP_CHECKOUT = "checkout"
+)
+
+type JournalStep struct {
+	Name string   `json:"name"`
+	Args []string `json:"args,omitempty"`
+	Done bool     `json:"done"`
+}
+
+type Journal struct {
+	Operation string    `json:"operation"`
+	StartedAt time.Time `json:"startedAt"`
+	// Where HEAD was before the first step, rolling back goes there
+	OrigHead   string        `json:"origHead"`
+	OrigBranch string        `json:"origBranch"`
+	Steps      []JournalStep `json:"steps"`
+
+	path string
+}
+
+func PullStep() JournalStep {
+	return JournalStep{Name: JOURNAL_STEP_PULL}
+}
+
+func PushStep() JournalStep {
+	return JournalStep{Name: JOURNAL_STEP_PUSH}
+}
+
+func UnlockStep(files []LockDatum, force bool) JournalStep {
+	args := []string{"no-force"}
+	if force {
+		args[0] = "force"
+	}
+	for _, file := range files {
+		args = append(args, file.ID)
+	}
+	return JournalStep{Name: JOURNAL_STEP_UNLOCK, Args: args}
+}
+
+func ResetStep(hash string) JournalStep {
+	return JournalStep{Name: JOURNAL_STEP_RESET, Args: []string{hash}}
+}
+
+func CheckoutStep(hash string) JournalStep {
+	return JournalStep{Name: JOURNAL_STEP_CHECKOUT, Args: []string{hash}}
+}
+
+func getJournalPath(repo *Repo) (string, error) {
+	paths, err := GetGitPaths(repo, JOURNAL_FILE)
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// Returns nil if the last operation finished
+func LoadJournal(repo *Repo) (*Journal, error) {
+	path, err := getJournalPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{path: path}
+	err = json.Unmarshal(data, journal)
+	if err != nil {
+		// half written, nothing we can trust in there
+		os.Remove(path)
+		return nil, nil
+	}
+	return journal, nil
+}
+
+func StartJournal(repo *Repo, operation string, steps ...JournalStep) (*Journal, error) {
+	existing, err := LoadJournal(repo)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("The last %s was interrupted, resume it or roll it back first", existing.Operation)
+	}
+
+	path, err := getJournalPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{
+		Operation: operation,
+		StartedAt: time.Now(),
+		Steps:     steps,
+		path:      path,
+	}
+
+	snapshot, err := GetRepoSnapshot(repo, false)
+	if err != nil {
+		return nil, err
+	}
+	journal.OrigHead = snapshot.Head
+	journal.OrigBranch = snapshot.Branch
+	if snapshot.DeatachedHead {
+		journal.OrigBranch = "HEAD"
+	}
+
+	return journal, journal.save()
+}
+
+func (journal *Journal) save() error {
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(journal.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// the rename makes sure a crash never leaves half a journal behind
+	tmpPath := journal.path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, journal.path)
+}
+
+// The operation is over, successfully or not, nothing to recover next time
+func (journal *Journal) Finish() error {
+	err := os.Remove(journal.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (journal *Journal) PendingSteps() []JournalStep {
+	retval := make([]JournalStep, 0)
+	for _, step := range journal.Steps {
+		if !step.Done {
+			retval = append(retval, step)
+		}
+	}
+	return retval
+}
+
+// Runs the steps not done yet, marking each one as soon as it finishes.
+// The journal is finished once every step is done. When a step fails or is cancelled it stays, so it can be resumed or rolled back.
+func RunJournal(repo *Repo, journal *Journal) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// dying in the middle of a pull can leave a rebase behind
+	operation, err := GetOngoingOperation(repo)
+	if err != nil {
+		return err
+	}
+	switch operation {
+	case GIT_OPERATION_NONE:
+	case GIT_OPERATION_REBASE:
+		err = FinishRebase(repo)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("Finish or abort the " + operation.String() + " underway first")
+	}
+
+	for idx := range journal.Steps {
+		if journal.Steps[idx].Done {
+			continue
+		}
+		err := runJournalStep(repo, journal, &journal.Steps[idx])
+		if err != nil {
+			return err
+		}
+		journal.Steps[idx].Done = true
+		err = journal.save()
+		if err != nil {
+			return err
+		}
+	}
+	return journal.Finish()
+}
+
+func runJournalStep(repo *Repo, journal *Journal, step *JournalStep) error {
+	switch step.Name {
+	case JOURNAL_STEP_PULL:
+		return GitSmartPull(repo)
+	case JOURNAL_STEP_PUSH:
+		return GitPush(repo)
+	case JOURNAL_STEP_UNLOCK:
+		if len(step.Args) == 0 {
+			return errors.New("Malformed unlock step")
+		}
+		// one at a time, crossing out each id as soon as it is unlocked, so resuming doesn't retry (and fail on) the ones already gone
+		force := step.Args[0] == "force"
+		ids := step.Args[1:]
+		remaining := []string{step.Args[0]}
+		unlockErrors := make([]error, 0)
+		for idx, id := range ids {
+			errs := UnlockLFSFiles(repo, []LockDatum{{ID: id}}, force)
+			if errs != nil {
+				remaining = append(remaining, id)
+				unlockErrors = append(unlockErrors, errs...)
+				continue
+			}
+			step.Args = append(append([]string{}, remaining...), ids[idx+1:]...)
+			err := journal.save()
+			if err != nil {
+				return err
+			}
+		}
+		if len(unlockErrors) > 0 {
+			return fmt.Errorf("%d files couldn't be unlocked: %w", len(unlockErrors), errors.Join(unlockErrors...))
+		}
+		return nil
+	case JOURNAL_STEP_RESET:
+		if len(step.Args) != 1 {
+			return errors.New("Malformed reset step")
+		}
+		return ResetHard(repo, step.Args[0])
+	case JOURNAL_STEP_CHECKOUT:
+		if len(step.Args) != 1 {
+			return errors.New("Malformed checkout step")
+		}
+		return Checkout(repo, step.Args[0])
+	}
+	return fmt.Errorf("Unknown step %s", step.Name)
+}
+
+// Aborts whatever git was doing and puts HEAD back where it was before the operation.
+// Pushes and unlocks already reached the server, those stay. Uncommited changes stay too, they are stashed while HEAD moves.
+func RollbackJournal(repo *Repo, journal *Journal) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	operation, err := GetOngoingOperation(repo)
+	if err != nil {
+		return err
+	}
+	if operation != GIT_OPERATION_NONE {
+		err = AbortOperation(repo)
+		if err != nil {
+			return err
+		}
+	}
+
+	if journal.OrigHead != "" {
+		snapshot, err := GetRepoSnapshot(repo, false)
+		if err != nil {
+			return err
+		}
+		sameBranch := snapshot.Branch == journal.OrigBranch || (snapshot.DeatachedHead && journal.OrigBranch == "HEAD")
+		if snapshot.Head != journal.OrigHead || !sameBranch {
+			err = restoreKeepingChanges(repo, snapshot, BackupDatum{Hash: journal.OrigHead, Branch: journal.OrigBranch}, journal.Operation)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return journal.Finish()
+}
+
+// RestoreBackup refuses to run on a dirty tree, and aborting a pull puts its autostash right back in the working tree
+func restoreKeepingChanges(repo *Repo, snapshot *RepoSnapshot, backup BackupDatum, operation string) error {
+	stashed := snapshot.ChangeAmount() > 0
+	if stashed {
+		_, err := repo.executeOneLine("stash", "push", "-m", "ugsg: changes kept while rolling back the "+operation)
+		if err != nil {
+			return fmt.Errorf("Could not put your changes aside to roll back: %w", err)
+		}
+	}
+
+	err := RestoreBackup(repo, backup)
+
+	if stashed {
+		// even when the restore failed, the changes go back where they were
+		_, popErr := repo.executeOneLine("stash", "pop")
+		if popErr != nil {
+			return errors.Join(err, fmt.Errorf("Your changes didn't apply cleanly after rolling back, they are still in the stash: %w", popErr))
+		}
+	}
+	return err
+}
+
+// Human readable list of the steps, done ones marked
+func (journal *Journal) Describe() string {
+	lines := make([]string, 0, len(journal.Steps))
+	for _, step := range journal.Steps {
+		mark := "[ ]"
+		if step.Done {
+			mark = "[x]"
+		}
+		lines = append(lines, mark+" "+step.Name)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+// testdata/lfs.json and lfs_pending_push.json: recorded on a repo with an LFS server, *.uasset and *.umap lockable, *.ini and *.cpp in LFS but not lockable.
+// Main.umap, Ñandú.uasset and "with: colon.uasset" are committed and not pushed.
+
+func TestGetLockableFiles(t *testing.T) {
+	repo, fake := replayRepo(t, "lfs.json")
+
+	files, err := GetLockableFiles(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Content/Maps/Main.umap",
+		"Content/Maps/Ñandú.uasset",
+		"Content/with: colon.uasset",
+		"Content/日本語/テスト.uasset",
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("got %q\nwant %q", files, want)
+	}
+	assertAllReplayed(t, fake)
+}
+
+func TestGetLockableFilesWithoutLFSFiles(t *testing.T) {
+	fake := (&FakeRunner{}).Add("", "lfs", "ls-files", "-n")
+	repo := OpenRepo(t.TempDir()).WithRunner(fake)
+
+	files, err := GetLockableFiles(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("got %q", files)
+	}
+}
+
+func TestGetLFSPendingPush(t *testing.T) {
+	repo, fake := replayRepo(t, "lfs_pending_push.json")
+
+	files, err := GetLFSPendingPush(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Content/Maps/Main.umap",
+		"Content/Maps/Ñandú.uasset",
+		"Content/with: colon.uasset",
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("got %q\nwant %q", files, want)
+	}
+	assertAllReplayed(t, fake)
+}
+
+func TestGetLFSPendingPushDetached(t *testing.T) {
+	recorded, err := LoadFakeRunner("testdata/status_detached.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := (&FakeRunner{}).Add(recorded.Calls[0].Output, "status", "--porcelain=v2", "--branch", "-z", "--untracked-files=no")
+	repo := OpenRepo(t.TempDir()).WithRunner(fake)
+
+	_, err = GetLFSPendingPush(repo)
+	if err == nil {
+		t.Error("expected an error with a detached HEAD")
+	}
+	assertAllReplayed(t, fake)
+}
+
+func TestParseLockableAttributes(t *testing.T) {
+	out := "a.uasset\x00lockable\x00set\x00b.txt\x00lockable\x00unspecified\x00c.umap\x00lockable\x00unset\x00d.uasset\x00lockable\x00set\x00"
+	files := parseLockableAttributes(out)
+	if !slices.Equal(files, []string{"a.uasset", "d.uasset"}) {
+		t.Errorf("got %q", files)
+	}
+	if len(parseLockableAttributes("")) != 0 {
+		t.Error("empty output should give no files")
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"errors"
+)
+
+// The commits that would end up on top of the upstream after Linearize, oldest first.
+// Merge commits are dropped and commits the upstream already has are skipped, same as git rebase does.
+func PreviewLinearize(repo *Repo) ([]*CommitDatum, error) {
+	if !HasUpstream(repo) {
+		return nil, errors.New("This branch doesn't track a remote branch, there is nothing to put the commits on top of")
+	}
+
+	commits := make([]*CommitDatum, 0)
+	err := readCommitLog(repo, nil, nil, func(commit *CommitDatum) {
+		commits = append(commits, commit)
+	}, "--no-merges", "--cherry-pick", "--right-only", "--reverse", "@{upstream}...HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+
+	return commits, nil
+}
+
+// Replays the unpushed commits on top of the upstream without the merge commits,
+// the way out of GIT_STATUS_LAST_COMMIT_MERGE.
+func Linearize(repo *Repo) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if IsUnrealRunning() {
+		return errors.New("Unreal is running, close it before fixing the history")
+	}
+
+	operation, err := GetOngoingOperation(repo)
+	if err != nil {
+		return err
+	}
+	if operation != GIT_OPERATION_NONE {
+		return errors.New("Finish or abort the " + operation.String() + " underway first")
+	}
+
+	_, err = CreateBackup(repo, BACKUP_OPERATION_LINEARIZE)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.executeOneLine("-c", "core.editor=true", "-c", "rebase.rebaseMerges=false", "rebase", "--autostash", "@{upstream}")
+	if err != nil {
+		operation, _ = GetOngoingOperation(repo)
+		if operation == GIT_OPERATION_REBASE {
+			return errors.New("Some of your commits conflict with the ones on the server, resolve the conflicts to finish")
+		}
+		return err
+	}
+	return nil
+}
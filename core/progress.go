@@ -0,0 +1,85 @@
+package core
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// One progress line from git or git-lfs, like "Receiving objects:  45% (450/1000), 1.20 MiB | 2.40 MiB/s"
+type Progress struct {
+	// "Receiving objects", "Resolving deltas", "Downloading LFS objects", "Filtering content"...
+	Phase string
+	// 0 to 100, -1 when git only gives a count (Total is 0 then)
+	Percent int
+	Current int64
+	Total   int64
+	// both as git prints them ("1.20 MiB", "2.40 MiB/s"), empty if the phase has no bytes
+	Transferred string
+	Rate        string
+	Done        bool
+}
+
+// Git commands that take --progress, the rest (reset, rebase) only report through LFS
+var PROGRESS_GIT_COMMANDS = []string{"fetch", "pull", "push", "clone", "checkout", "switch"}
+
+var progressPercentRegex = regexp.MustCompile(`^([A-Za-z][A-Za-z ]*?):\s+(\d+)% \((\d+)/(\d+)\)(?:, ([^|]+?)(?: \| (.+?))?)?$`)
+var progressCountRegex = regexp.MustCompile(`^([A-Za-z][A-Za-z ]*?):\s+(\d+)$`)
+
+func ParseProgressLine(line string) (Progress, bool) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "remote: "))
+
+	progress := Progress{}
+	for _, suffix := range []string{", done.", ", done"} {
+		if strings.HasSuffix(line, suffix) {
+			line = strings.TrimSuffix(line, suffix)
+			progress.Done = true
+			break
+		}
+	}
+
+	match := progressPercentRegex.FindStringSubmatch(line)
+	if match != nil {
+		progress.Phase = match[1]
+		progress.Percent, _ = strconv.Atoi(match[2])
+		progress.Current, _ = strconv.ParseInt(match[3], 10, 64)
+		progress.Total, _ = strconv.ParseInt(match[4], 10, 64)
+		progress.Transferred = strings.TrimSpace(match[5])
+		progress.Rate = strings.TrimSpace(match[6])
+		return progress, true
+	}
+
+	match = progressCountRegex.FindStringSubmatch(line)
+	if match != nil {
+		progress.Phase = match[1]
+		progress.Percent = -1
+		progress.Current, _ = strconv.ParseInt(match[2], 10, 64)
+		return progress, true
+	}
+
+	return Progress{}, false
+}
+
+// bufio.SplitFunc for progress output, git redraws the same line ending it with \r instead of \n
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	idx := bytes.IndexAny(data, "\r\n")
+	if idx >= 0 {
+		// \r\n is a single line end
+		if data[idx] == '\r' && idx+1 < len(data) && data[idx+1] == '\n' {
+			return idx + 2, data[:idx], nil
+		}
+		if data[idx] == '\r' && idx+1 == len(data) && !atEOF {
+			// might be the first half of a \r\n, wait for more
+			return 0, nil, nil
+		}
+		return idx + 1, data[:idx], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
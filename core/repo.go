@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Everything core needs to know to work on a repository
+type Repo struct {
+	Path   string
+	Config Config
+
+	// set on the copy handed out by lock()
+	holdsLock bool
+
+	// cancels every command run through this repo, see WithContext
+	ctx context.Context
+	// gets the progress of long commands, see WithProgress
+	onProgress func(Progress)
+	// runs the git commands, nil is ExecRunner, see WithRunner
+	runner Runner
+}
+
+// Git commands that can legitimately take ages on a big project
+var LONG_GIT_COMMANDS = []string{"fetch", "pull", "push", "clone", "lfs", "checkout", "switch", "reset", "rebase", "merge", "cherry-pick"}
+
+// Returns a copy of the repo with other settings, the repo itself is left alone for whoever is still using it
+func (repo *Repo) WithConfig(config Config) *Repo {
+	retval := *repo
+	retval.Config = config
+	return &retval
+}
+
+// Returns a copy of the repo whose commands get killed when ctx is done
+func (repo *Repo) WithContext(ctx context.Context) *Repo {
+	retval := *repo
+	retval.ctx = ctx
+	return &retval
+}
+
+// Returns a copy of the repo that reports the progress of fetches, pulls, pushes, checkouts and LFS transfers
+func (repo *Repo) WithProgress(onProgress func(Progress)) *Repo {
+	retval := *repo
+	retval.onProgress = onProgress
+	return &retval
+}
+
+// Returns a copy of the repo that runs its commands through runner, for tests
+func (repo *Repo) WithRunner(runner Runner) *Repo {
+	retval := *repo
+	retval.runner = runner
+	return &retval
+}
+
+func (repo *Repo) Runner() Runner {
+	if repo.runner == nil {
+		return ExecRunner{}
+	}
+	return repo.runner
+}
+
+func (repo *Repo) Context() context.Context {
+	if repo.ctx == nil {
+		return context.Background()
+	}
+	return repo.ctx
+}
+
+// Skips the "-c key=value" pairs in front to find the git subcommand, -1 if there is none
+func gitSubcommandIndex(args []string) int {
+	for idx := 0; idx < len(args); idx++ {
+		if args[idx] == "-c" {
+			idx++
+			continue
+		}
+		return idx
+	}
+	return -1
+}
+
+func gitSubcommand(args []string) string {
+	idx := gitSubcommandIndex(args)
+	if idx == -1 {
+		return ""
+	}
+	return args[idx]
+}
+
+// The repo context with the configured timeout for this command on top
+func (repo *Repo) commandContext(args []string) (context.Context, context.CancelFunc) {
+	timeout := repo.Config.CommandTimeout
+	if slices.Contains(LONG_GIT_COMMANDS, gitSubcommand(args)) {
+		timeout = repo.Config.LongCommandTimeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(repo.Context())
+	}
+	return context.WithTimeout(repo.Context(), time.Duration(timeout)*time.Second)
+}
+
+// Loads the project settings from the ugsg.json in the repo, if there is one
+func OpenRepo(repoPath string) *Repo {
+	return &Repo{
+		Path:   repoPath,
+		Config: LoadConfig(filepath.Join(repoPath, CONFIG_FILE)),
+	}
+}
+
+// For a ugsg.json shipped inside the project, the repo path is relative to the json file
+func OpenRepoFromConfig(config Config) *Repo {
+	repoPath := config.RepoPath
+	if !filepath.IsAbs(repoPath) {
+		repoPath = filepath.Join(filepath.Dir(config.ConfigPath), repoPath)
+	}
+	absPath, err := filepath.Abs(repoPath)
+	if err == nil {
+		repoPath = absPath
+	}
+
+	return &Repo{
+		Path:   repoPath,
+		Config: config,
+	}
+}
+
+// Every git error leaves core through here: git missing, not a repo, or whatever ClassifyError recognizes
+func (repo *Repo) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrApplicationNotFound) {
+		return fmt.Errorf("%w (%w)", ErrGitMissing, err)
+	}
+	var execErr ErrExec
+	if errors.As(err, &execErr) && strings.Contains(strings.ToLower(execErr.Output+execErr.ErrOutput), "not a git repository") {
+		return fmt.Errorf("%w: %s (%w)", ErrNotARepo, repo.Path, err)
+	}
+	return classifyError(err, repo.Config.RemoteName)
+}
+
+func (repo *Repo) execute(args ...string) ([]string, error) {
+	ctx, cancel := repo.commandContext(args)
+	defer cancel()
+	out, err := repo.Runner().Run(ctx, repo.Path, repo.Config.GitExecPath, args...)
+	if err != nil {
+		return nil, repo.wrapError(err)
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func (repo *Repo) executeOneLine(args ...string) (string, error) {
+	ctx, cancel := repo.commandContext(args)
+	defer cancel()
+	out, err := repo.Runner().Run(ctx, repo.Path, repo.Config.GitExecPath, args...)
+	return out, repo.wrapError(err)
+}
+
+func (repo *Repo) executeStdout(args ...string) (string, error) {
+	ctx, cancel := repo.commandContext(args)
+	defer cancel()
+	out, err := repo.Runner().RunStdout(ctx, repo.Path, repo.Config.GitExecPath, args...)
+	return out, repo.wrapError(err)
+}
+
+// For commands that can take long, reports their progress if the repo has someone listening (WithProgress)
+func (repo *Repo) executeProgress(args ...string) (string, error) {
+	if repo.onProgress == nil {
+		return repo.executeOneLine(args...)
+	}
+
+	idx := gitSubcommandIndex(args)
+	if idx != -1 && slices.Contains(PROGRESS_GIT_COMMANDS, args[idx]) {
+		args = slices.Insert(slices.Clone(args), idx+1, "--progress")
+	}
+
+	ctx, cancel := repo.commandContext(args)
+	defer cancel()
+	out, err := repo.Runner().RunProgress(ctx, repo.Path, repo.onProgress, repo.Config.GitExecPath, args...)
+	return out, repo.wrapError(err)
+}
+
+func (repo *Repo) executeStream(stdin io.Reader, lineCallback func(string) error, args ...string) error {
+	ctx, cancel := repo.commandContext(args)
+	defer cancel()
+	return repo.wrapError(repo.Runner().RunStream(ctx, repo.Path, stdin, lineCallback, repo.Config.GitExecPath, args...))
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var (
+	// Some git program is working on the repo right now
+	ErrIndexLocked = errors.New("Another git program is working on this repository, wait for it to finish or close it")
+	// index.lock left behind by a git that crashed or got killed, nothing is running
+	ErrStaleIndexLock = errors.New("A crashed git left the repository locked (index.lock). Remove the lock from the repository status to continue")
+)
+
+// A channel with room for one instead of a sync.Mutex, so waiting for it can be cancelled
+var repoMutexes = make(map[string]chan struct{})
+var repoMutexesMutex sync.Mutex
+
+func getRepoMutex(repo *Repo) chan struct{} {
+	key, err := filepath.Abs(repo.Path)
+	if err != nil {
+		key = repo.Path
+	}
+
+	repoMutexesMutex.Lock()
+	defer repoMutexesMutex.Unlock()
+
+	mutex, ok := repoMutexes[key]
+	if !ok {
+		mutex = make(chan struct{}, 1)
+		repoMutexes[key] = mutex
+	}
+	return mutex
+}
+
+// Every operation that changes the repo goes through here, only one at a time per repo.
+// Waits for the operation running before it, unless the repo context is cancelled first.
+// The returned repo carries the lock, operations called with it don't try to take it again.
+func (repo *Repo) lock() (*Repo, func(), error) {
+	if repo.holdsLock {
+		return repo, func() {}, nil
+	}
+
+	mutex := getRepoMutex(repo)
+	ctx := repo.Context()
+	select {
+	case mutex <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, fmt.Errorf("Stopped while waiting for another operation on the repository: %w", ctx.Err())
+	}
+
+	locked := *repo
+	locked.holdsLock = true
+	return &locked, func() { <-mutex }, nil
+}
+
+// Same as lock, for operations that touch the index or the working tree and would trip on an index.lock
+func (repo *Repo) lockIndex() (*Repo, func(), error) {
+	if repo.holdsLock {
+		return repo, func() {}, nil
+	}
+
+	locked, unlock, err := repo.lock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkIndexLock(locked)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	return locked, unlock, nil
+}
+
+func getIndexLockPath(repo *Repo) (string, error) {
+	paths, err := GetGitPaths(repo, "index.lock")
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// A lock this recent can belong to a git that just started, like a status refreshing the index
+const INDEX_LOCK_GRACE = 10 * time.Second
+
+// Only gits working on this repo count, ours are left out: while we hold the repo lock we know it's us,
+// and the ones we run without it (status, fetch) are short and covered by INDEX_LOCK_GRACE
+func isGitRunningIn(repo *Repo) bool {
+	worktree, err := filepath.Abs(repo.Path)
+	if err != nil {
+		worktree = repo.Path
+	}
+	ownPid := int32(os.Getpid())
+
+	allProcs, _ := process.Processes()
+	for _, proc := range allProcs {
+		procName, _ := proc.Name()
+		procName = strings.TrimSuffix(strings.ToLower(procName), ".exe")
+		if procName != "git" {
+			continue
+		}
+		if parent, err := proc.Ppid(); err == nil && parent == ownPid {
+			continue
+		}
+		if cwd, err := proc.Cwd(); err == nil && isInside(cwd, worktree) {
+			return true
+		}
+		// git -C <repo> and such run from anywhere
+		args, _ := proc.CmdlineSlice()
+		for _, arg := range args {
+			if isInside(arg, worktree) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isInside(path string, dir string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+	dir = filepath.Clean(dir)
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+		dir = strings.ToLower(dir)
+	}
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// Returns ErrIndexLocked or ErrStaleIndexLock if there is an index.lock in the way
+func checkIndexLock(repo *Repo) error {
+	lockPath, err := getIndexLockPath(repo)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(lockPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if time.Since(info.ModTime()) < INDEX_LOCK_GRACE {
+		return ErrIndexLocked
+	}
+	// one of our operations is running, its git holds the lock
+	if !repo.holdsLock && len(getRepoMutex(repo)) > 0 {
+		return ErrIndexLocked
+	}
+	if isGitRunningIn(repo) {
+		return ErrIndexLocked
+	}
+	return ErrStaleIndexLock
+}
+
+func HasStaleIndexLock(repo *Repo) (bool, error) {
+	err := checkIndexLock(repo)
+	if errors.Is(err, ErrStaleIndexLock) {
+		return true, nil
+	}
+	if errors.Is(err, ErrIndexLocked) {
+		return false, nil
+	}
+	return false, err
+}
+
+// Only removes it when no git is running, a live lock belongs to someone
+func RemoveStaleIndexLock(repo *Repo) error {
+	repo, unlock, err := repo.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = checkIndexLock(repo)
+	if !errors.Is(err, ErrStaleIndexLock) {
+		return err
+	}
+
+	lockPath, err := getIndexLockPath(repo)
+	if err != nil {
+		return err
+	}
+	return os.Remove(lockPath)
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLockWaitsForTheOperationBefore(t *testing.T) {
+	repo := OpenRepo(t.TempDir())
+	_, unlock, err := repo.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		_, unlockSecond, err := repo.lock()
+		if err != nil {
+			t.Error(err)
+			close(acquired)
+			return
+		}
+		unlockSecond()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("the second lock didn't wait for the first one")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second lock never got it")
+	}
+}
+
+func TestLockStopsWaitingWhenCancelled(t *testing.T) {
+	repo := OpenRepo(t.TempDir())
+	_, unlock, err := repo.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	_, _, err = repo.WithContext(ctx).lock()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+// A repo with an index.lock last touched age ago
+func lockedRepo(t *testing.T, age time.Duration) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	path := t.TempDir()
+	out, err := exec.Command("git", "init", "--quiet", path).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	lockPath := filepath.Join(path, ".git", "index.lock")
+	err = os.WriteFile(lockPath, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	touched := time.Now().Add(-age)
+	err = os.Chtimes(lockPath, touched, touched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return OpenRepo(path)
+}
+
+// A git that isn't our child, waiting on its stdin until the test ends
+func startForeignGit(t *testing.T, dir string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	// the "; true" keeps sh around, otherwise it execs git and git would be our child
+	c := exec.Command("sh", "-c", "git cat-file --batch; true")
+	c.Dir = dir
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		c.Wait()
+	})
+	// give it time to show up in the process list
+	time.Sleep(200 * time.Millisecond)
+}
+
+func TestIndexLock(t *testing.T) {
+	t.Run("recent lock is live", func(t *testing.T) {
+		repo := lockedRepo(t, 0)
+		stale, err := HasStaleIndexLock(repo)
+		if err != nil || stale {
+			t.Errorf("got %v %v, want not stale", stale, err)
+		}
+	})
+	t.Run("old lock is stale", func(t *testing.T) {
+		repo := lockedRepo(t, time.Minute)
+		stale, err := HasStaleIndexLock(repo)
+		if err != nil || !stale {
+			t.Errorf("got %v %v, want stale", stale, err)
+		}
+	})
+	t.Run("git in another repo doesn't count", func(t *testing.T) {
+		repo := lockedRepo(t, time.Minute)
+		other := lockedRepo(t, time.Minute)
+		startForeignGit(t, other.Path)
+		stale, err := HasStaleIndexLock(repo)
+		if err != nil || !stale {
+			t.Errorf("got %v %v, want stale", stale, err)
+		}
+	})
+	t.Run("git in this repo holds it", func(t *testing.T) {
+		repo := lockedRepo(t, time.Minute)
+		startForeignGit(t, filepath.Join(repo.Path, ".git"))
+		stale, err := HasStaleIndexLock(repo)
+		if err != nil || stale {
+			t.Errorf("got %v %v, want not stale", stale, err)
+		}
+	})
+	t.Run("our own operation holds it", func(t *testing.T) {
+		repo := lockedRepo(t, time.Minute)
+		_, unlock, err := repo.lock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer unlock()
+		stale, err := HasStaleIndexLock(repo)
+		if err != nil || stale {
+			t.Errorf("got %v %v, want not stale", stale, err)
+		}
+	})
+}
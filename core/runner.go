@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Runs the commands of a repo. ExecRunner runs them for real, FakeRunner replays recorded outputs so the parsers can be tested without git.
+type Runner interface {
+	// stdout and stderr together
+	Run(ctx context.Context, workingDir, command string, args ...string) (string, error)
+	RunStdout(ctx context.Context, workingDir, command string, args ...string) (string, error)
+	RunProgress(ctx context.Context, workingDir string, onProgress func(Progress), command string, args ...string) (string, error)
+	RunStream(ctx context.Context, workingDir string, stdin io.Reader, lineCallback func(string) error, command string, args ...string) error
+}
+
+type ExecRunner struct{}
+
+func (ExecRunner) Run(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	return ExecuteOneLineContext(ctx, workingDir, command, args...)
+}
+
+func (ExecRunner) RunStdout(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	return ExecuteStdoutContext(ctx, workingDir, command, args...)
+}
+
+func (ExecRunner) RunProgress(ctx context.Context, workingDir string, onProgress func(Progress), command string, args ...string) (string, error) {
+	return ExecuteProgressContext(ctx, workingDir, onProgress, command, args...)
+}
+
+func (ExecRunner) RunStream(ctx context.Context, workingDir string, stdin io.Reader, lineCallback func(string) error, command string, args ...string) error {
+	return ExecuteStreamContext(ctx, workingDir, stdin, lineCallback, command, args...)
+}
+
+// One command and what it answered. Args don't include the git executable, it changes from machine to machine.
+type RecordedCall struct {
+	Args   []string `json:"args"`
+	Output string   `json:"output"`
+	// 0 when the command worked, -1 when it didn't even run
+	ExitCode  int    `json:"exitCode,omitempty"`
+	ErrOutput string `json:"errOutput,omitempty"`
+}
+
+func (call RecordedCall) err(command string) error {
+	if call.ExitCode == 0 {
+		return nil
+	}
+	if call.ExitCode == -1 {
+		return errors.New(call.ErrOutput)
+	}
+	return ErrExec{
+		ExitCode:  call.ExitCode,
+		Output:    strings.TrimSpace(call.Output),
+		ErrOutput: strings.TrimSpace(call.ErrOutput),
+		Cmd:       command,
+		Args:      call.Args,
+	}
+}
+
+// Answers every command with a recorded call that has the same args, each recorded call is used once and in order
+type FakeRunner struct {
+	Calls []RecordedCall
+
+	used  []bool
+	mutex sync.Mutex
+}
+
+// Loads the calls saved by RecordingRunner.Save
+func LoadFakeRunner(path string) (*FakeRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fake := &FakeRunner{}
+	err = json.Unmarshal(data, &fake.Calls)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the recorded calls in %s: %w", path, err)
+	}
+	return fake, nil
+}
+
+// Adds a call that worked
+func (fake *FakeRunner) Add(output string, args ...string) *FakeRunner {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.Calls = append(fake.Calls, RecordedCall{Args: args, Output: output})
+	return fake
+}
+
+// Adds a call that failed with exitCode
+func (fake *FakeRunner) AddError(exitCode int, errOutput string, args ...string) *FakeRunner {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.Calls = append(fake.Calls, RecordedCall{Args: args, ExitCode: exitCode, ErrOutput: errOutput})
+	return fake
+}
+
+// Calls that were never replayed, a test usually wants this empty
+func (fake *FakeRunner) Unused() []RecordedCall {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	retval := make([]RecordedCall, 0)
+	for idx, call := range fake.Calls {
+		if idx >= len(fake.used) || !fake.used[idx] {
+			retval = append(retval, call)
+		}
+	}
+	return retval
+}
+
+func (fake *FakeRunner) replay(command string, args []string) (string, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	for len(fake.used) < len(fake.Calls) {
+		fake.used = append(fake.used, false)
+	}
+	for idx, call := range fake.Calls {
+		if !fake.used[idx] && slices.Equal(call.Args, args) {
+			fake.used[idx] = true
+			return call.Output, call.err(command)
+		}
+	}
+	return "", fmt.Errorf("FakeRunner has nothing recorded for %s %s", command, strings.Join(args, " "))
+}
+
+func (fake *FakeRunner) Run(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	return fake.replay(command, args)
+}
+
+func (fake *FakeRunner) RunStdout(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	return fake.replay(command, args)
+}
+
+// Progress isn't recorded, onProgress is never called
+func (fake *FakeRunner) RunProgress(ctx context.Context, workingDir string, onProgress func(Progress), command string, args ...string) (string, error) {
+	return fake.replay(command, args)
+}
+
+func (fake *FakeRunner) RunStream(ctx context.Context, workingDir string, stdin io.Reader, lineCallback func(string) error, command string, args ...string) error {
+	out, err := fake.replay(command, args)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.SplitAfter(out, "\n") {
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			continue
+		}
+		err := lineCallback(line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Runs the commands with Runner and keeps what they answered, Save them to replay them later with LoadFakeRunner
+type RecordingRunner struct {
+	Runner Runner
+	Calls  []RecordedCall
+
+	mutex sync.Mutex
+}
+
+func (recorder *RecordingRunner) record(args []string, out string, err error) {
+	call := RecordedCall{Args: slices.Clone(args), Output: out}
+	var execErr ErrExec
+	if errors.As(err, &execErr) {
+		call.Output = execErr.Output
+		call.ExitCode = execErr.ExitCode
+		call.ErrOutput = execErr.ErrOutput
+	} else if err != nil {
+		call.ExitCode = -1
+		call.ErrOutput = err.Error()
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.Calls = append(recorder.Calls, call)
+}
+
+func (recorder *RecordingRunner) Run(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	out, err := recorder.Runner.Run(ctx, workingDir, command, args...)
+	recorder.record(args, out, err)
+	return out, err
+}
+
+func (recorder *RecordingRunner) RunStdout(ctx context.Context, workingDir, command string, args ...string) (string, error) {
+	out, err := recorder.Runner.RunStdout(ctx, workingDir, command, args...)
+	recorder.record(args, out, err)
+	return out, err
+}
+
+func (recorder *RecordingRunner) RunProgress(ctx context.Context, workingDir string, onProgress func(Progress), command string, args ...string) (string, error) {
+	out, err := recorder.Runner.RunProgress(ctx, workingDir, onProgress, command, args...)
+	recorder.record(args, out, err)
+	return out, err
+}
+
+func (recorder *RecordingRunner) RunStream(ctx context.Context, workingDir string, stdin io.Reader, lineCallback func(string) error, command string, args ...string) error {
+	out := &strings.Builder{}
+	err := recorder.Runner.RunStream(ctx, workingDir, stdin, func(line string) error {
+		out.WriteString(line + "\n")
+		return lineCallback(line)
+	}, command, args...)
+	recorder.record(args, out.String(), err)
+	return err
+}
+
+func (recorder *RecordingRunner) Save(path string) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	data, err := json.MarshalIndent(recorder.Calls, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
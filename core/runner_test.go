@@ -0,0 +1,164 @@
+package core
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// A repo in an empty folder that answers with the calls recorded in testdata/<name>
+func replayRepo(t *testing.T, name string) (*Repo, *FakeRunner) {
+	t.Helper()
+	fake, err := LoadFakeRunner(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return OpenRepo(t.TempDir()).WithRunner(fake), fake
+}
+
+func assertAllReplayed(t *testing.T, fake *FakeRunner) {
+	t.Helper()
+	for _, call := range fake.Unused() {
+		t.Errorf("recorded call never replayed: %q", call.Args)
+	}
+}
+
+func TestFakeRunnerReplaysInOrder(t *testing.T) {
+	fake := (&FakeRunner{}).
+		Add("first\n", "log", "-1").
+		Add("second\n", "log", "-1")
+	repo := OpenRepo(t.TempDir()).WithRunner(fake)
+
+	for _, want := range []string{"first\n", "second\n"} {
+		out, err := repo.executeOneLine("log", "-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != want {
+			t.Errorf("got %q, want %q", out, want)
+		}
+	}
+
+	_, err := repo.executeOneLine("log", "-1")
+	if err == nil {
+		t.Error("expected an error once the recorded calls are used up")
+	}
+}
+
+func TestFakeRunnerErrorsGoThroughWrapError(t *testing.T) {
+	fake := (&FakeRunner{}).
+		AddError(128, "fatal: not a git repository (or any of the parent directories): .git", "rev-parse", "--git-dir").
+		AddError(1, "error: failed to push some refs to 'origin'\nhint: Updates were rejected because the tip of your current branch is behind", "push", "origin")
+	repo := OpenRepo(t.TempDir()).WithRunner(fake)
+
+	err := CheckRepo(repo)
+	if !errors.Is(err, ErrNotARepo) {
+		t.Errorf("CheckRepo: got %v, want ErrNotARepo", err)
+	}
+
+	_, err = repo.executeOneLine("push", "origin")
+	var actionable *ActionableError
+	if !errors.As(err, &actionable) || actionable.Kind != ERROR_KIND_NON_FAST_FORWARD {
+		t.Errorf("push: got %v, want a non fast forward error", err)
+	}
+	var execErr ErrExec
+	if !errors.As(err, &execErr) || execErr.ExitCode != 1 {
+		t.Errorf("push: the ErrExec with the exit code should still be there, got %v", err)
+	}
+}
+
+func TestNotARepoInAnotherLanguage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// a german git says "Kein Git-Repository"
+	t.Setenv("LC_ALL", "C.UTF-8")
+	t.Setenv("LANGUAGE", "de")
+
+	err := CheckRepo(OpenRepo(t.TempDir()))
+	if !errors.Is(err, ErrNotARepo) {
+		t.Errorf("got %v, want ErrNotARepo", err)
+	}
+}
+
+func TestErrorExplanationUsesTheConfiguredRemote(t *testing.T) {
+	fake := (&FakeRunner{}).AddError(2, "Error downloading object: Content/Hero.uasset (4d7a214): Smudge error: Error downloading Content/Hero.uasset: [4d7a214] Object does not exist on the server", "lfs", "pull")
+	repo := OpenRepo(t.TempDir()).WithRunner(fake)
+	repo.Config.RemoteName = "upstream"
+
+	_, err := repo.executeOneLine("lfs", "pull")
+	var actionable *ActionableError
+	if !errors.As(err, &actionable) || actionable.Kind != ERROR_KIND_MISSING_LFS_OBJECT {
+		t.Fatalf("got %v, want a missing LFS object error", err)
+	}
+	if !strings.Contains(actionable.Explanation, "git lfs push --all upstream") {
+		t.Errorf("got %q", actionable.Explanation)
+	}
+}
+
+func TestFakeRunnerStreamsLines(t *testing.T) {
+	fake := (&FakeRunner{}).Add("a\nb\n\nc", "cat-file", "--batch-check")
+	repo := OpenRepo(t.TempDir()).WithRunner(fake)
+
+	lines := make([]string, 0)
+	err := repo.executeStream(nil, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	}, "cat-file", "--batch-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(lines, []string{"a", "b", "c"}) {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestRecordingRunnerRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	_, err := Execute(dir, "git", "init", "-q", "-b", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &RecordingRunner{Runner: ExecRunner{}}
+	recorded, err := GetRepoSnapshot(OpenRepo(dir).WithRunner(recorder), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fails on purpose, errors have to survive the trip too
+	_, recordedErr := OpenRepo(dir).WithRunner(recorder).executeOneLine("rev-parse", "--verify", "-q", "HEAD")
+	if recordedErr == nil {
+		t.Fatal("rev-parse HEAD should fail on a repo without commits")
+	}
+
+	cassette := filepath.Join(t.TempDir(), "calls.json")
+	err = recorder.Save(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, err := LoadFakeRunner(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := OpenRepo(t.TempDir()).WithRunner(fake)
+	replayed, err := GetRepoSnapshot(repo, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Branch != recorded.Branch || replayed.Head != recorded.Head {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	_, replayedErr := repo.executeOneLine("rev-parse", "--verify", "-q", "HEAD")
+	var recordedExec, replayedExec ErrExec
+	if !errors.As(recordedErr, &recordedExec) || !errors.As(replayedErr, &replayedExec) || recordedExec.ExitCode != replayedExec.ExitCode {
+		t.Errorf("replayed error %v, recorded %v", replayedErr, recordedErr)
+	}
+	assertAllReplayed(t, fake)
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// One file out of git status. X is the index (staged) state and Y the working tree state,
+// '.' means unchanged, see git status --porcelain=v2 for the rest of the letters.
+type FileStatus struct {
+	Path string
+	// Where the file came from when it was renamed or copied
+	OrigPath   string
+	X          byte
+	Y          byte
+	Untracked  bool
+	Conflicted bool
+}
+
+func (file FileStatus) IsRename() bool {
+	return file.OrigPath != ""
+}
+
+// Everything git status knows about the repo, read with a single call
+type RepoSnapshot struct {
+	// Commit checked out, empty on a repo without commits
+	Head string
+	// Empty when the HEAD is deatached
+	Branch        string
+	DeatachedHead bool
+	// Empty when the branch doesn't track anything
+	Upstream string
+	Ahead    int
+	Behind   int
+	Files    []FileStatus
+
+	// git skips the ahead/behind line when the upstream is gone
+	aheadBehindKnown bool
+}
+
+func (snapshot *RepoSnapshot) HasUpstream() bool {
+	return snapshot.Upstream != ""
+}
+
+// Same as GetAheadBehind, fails when there is no upstream to compare with
+func (snapshot *RepoSnapshot) AheadBehind() (int, int, error) {
+	if !snapshot.aheadBehindKnown {
+		return 0, 0, errors.New("Could not find ahead/behind")
+	}
+	return snapshot.Ahead, snapshot.Behind, nil
+}
+
+// Number of entries git status reports, untracked ones included if they were read
+func (snapshot *RepoSnapshot) ChangeAmount() int {
+	return len(snapshot.Files)
+}
+
+// Paths of the changed files, renames add both the old and the new path
+func (snapshot *RepoSnapshot) FilePaths(excludeUntracked bool) []string {
+	dedupMap := make(map[string]bool)
+	retval := make([]string, 0, len(snapshot.Files))
+	add := func(path string) {
+		if path != "" && !dedupMap[path] {
+			dedupMap[path] = true
+			retval = append(retval, path)
+		}
+	}
+
+	for _, file := range snapshot.Files {
+		if excludeUntracked && file.Untracked {
+			continue
+		}
+		add(file.OrigPath)
+		add(file.Path)
+	}
+	return retval
+}
+
+func (snapshot *RepoSnapshot) ConflictedFiles() []string {
+	retval := make([]string, 0)
+	for _, file := range snapshot.Files {
+		if file.Conflicted {
+			retval = append(retval, file.Path)
+		}
+	}
+	return retval
+}
+
+func GetRepoSnapshot(repo *Repo, includeUntracked bool) (*RepoSnapshot, error) {
+	untracked := "--untracked-files=all"
+	if !includeUntracked {
+		untracked = "--untracked-files=no"
+	}
+	out, err := repo.executeStdout("status", "--porcelain=v2", "--branch", "-z", untracked)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatusPorcelainV2(out)
+}
+
+// Parses the output of git status --porcelain=v2 --branch -z
+func ParseStatusPorcelainV2(out string) (*RepoSnapshot, error) {
+	snapshot := &RepoSnapshot{Files: make([]FileStatus, 0)}
+
+	records := strings.Split(out, "\x00")
+	for idx := 0; idx < len(records); idx++ {
+		record := records[idx]
+		if record == "" {
+			continue
+		}
+
+		switch record[0] {
+		case '#':
+			parseBranchHeader(snapshot, record)
+		case '1':
+			// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+			fields := strings.SplitN(record, " ", 9)
+			if len(fields) != 9 || len(fields[1]) != 2 {
+				return nil, fmt.Errorf("Unexpected status line: %s", record)
+			}
+			snapshot.Files = append(snapshot.Files, FileStatus{Path: fields[8], X: fields[1][0], Y: fields[1][1]})
+		case '2':
+			// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path> and the original path on the next record
+			fields := strings.SplitN(record, " ", 10)
+			if len(fields) != 10 || len(fields[1]) != 2 || idx+1 >= len(records) || records[idx+1] == "" {
+				return nil, fmt.Errorf("Unexpected status line: %s", record)
+			}
+			idx++
+			snapshot.Files = append(snapshot.Files, FileStatus{Path: fields[9], OrigPath: records[idx], X: fields[1][0], Y: fields[1][1]})
+		case 'u':
+			// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) != 11 || len(fields[1]) != 2 {
+				return nil, fmt.Errorf("Unexpected status line: %s", record)
+			}
+			snapshot.Files = append(snapshot.Files, FileStatus{Path: fields[10], X: fields[1][0], Y: fields[1][1], Conflicted: true})
+		case '?':
+			snapshot.Files = append(snapshot.Files, FileStatus{Path: strings.TrimPrefix(record, "? "), X: '?', Y: '?', Untracked: true})
+		case '!':
+			// ignored, we never ask for them
+		default:
+			return nil, fmt.Errorf("Unexpected status line: %s", record)
+		}
+	}
+
+	if snapshot.Branch == "" && !snapshot.DeatachedHead {
+		return nil, errors.New("Could not find the branch in git status")
+	}
+
+	return snapshot, nil
+}
+
+func parseBranchHeader(snapshot *RepoSnapshot, record string) {
+	key, value, _ := strings.Cut(strings.TrimPrefix(record, "# "), " ")
+	switch key {
+	case "branch.oid":
+		if value != "(initial)" {
+			snapshot.Head = value
+		}
+	case "branch.head":
+		if value == "(detached)" {
+			snapshot.DeatachedHead = true
+		} else {
+			snapshot.Branch = value
+		}
+	case "branch.upstream":
+		snapshot.Upstream = value
+	case "branch.ab":
+		// +<ahead> -<behind>
+		ahead, behind, _ := strings.Cut(value, " ")
+		snapshot.Ahead, _ = strconv.Atoi(strings.TrimPrefix(ahead, "+"))
+		snapshot.Behind, _ = strconv.Atoi(strings.TrimPrefix(behind, "-"))
+		snapshot.aheadBehindKnown = true
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+// testdata/status.json: main is 1 ahead and 2 behind origin/main, with a rename to a unicode name,
+// a tab and quotes in file names, a staged delete, a file both staged and modified and an untracked file in a unicode folder.
+
+func TestGetWorkingTreeFiles(t *testing.T) {
+	repo, fake := replayRepo(t, "status.json")
+
+	files, err := GetWorkingTreeFiles(repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Config/tab\there.ini",
+		"Content/Gone.uasset",
+		"Content/Maps/Main.umap",
+		// renames give the old path and the new one
+		"Content/Old Name.uasset",
+		"Content/Maps/Ñandú.uasset",
+		`Source/"quoted".cpp`,
+		"Content/日本語/テスト.uasset",
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("got %q\nwant %q", files, want)
+	}
+
+	ahead, behind, err := GetAheadBehind(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ahead != 1 || behind != 2 {
+		t.Errorf("got ahead %d behind %d, want 1 and 2", ahead, behind)
+	}
+	assertAllReplayed(t, fake)
+}
+
+// testdata/status_no_untracked.json: the same changes as status.json, recorded without the untracked files
+func TestGetWorkingTreeFilesExcludeUntracked(t *testing.T) {
+	repo, fake := replayRepo(t, "status_no_untracked.json")
+
+	files, err := GetWorkingTreeFiles(repo, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(files, "Content/日本語/テスト.uasset") {
+		t.Errorf("untracked file listed: %q", files)
+	}
+	if len(files) != 6 {
+		t.Errorf("got %d files, want 6: %q", len(files), files)
+	}
+	assertAllReplayed(t, fake)
+}
+
+func TestRepoSnapshotFileStatus(t *testing.T) {
+	repo, _ := replayRepo(t, "status.json")
+	snapshot, err := GetRepoSnapshot(repo, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshot.Branch != "main" || snapshot.Upstream != "origin/main" || snapshot.DeatachedHead {
+		t.Errorf("wrong branch info: %+v", snapshot)
+	}
+	if snapshot.ChangeAmount() != 6 {
+		t.Errorf("got %d changes, want 6", snapshot.ChangeAmount())
+	}
+
+	tests := []struct {
+		path      string
+		origPath  string
+		x, y      byte
+		untracked bool
+	}{
+		{"Config/tab\there.ini", "", '.', 'M', false},
+		{"Content/Gone.uasset", "", 'D', '.', false},
+		{"Content/Maps/Main.umap", "", 'M', 'M', false},
+		{"Content/Maps/Ñandú.uasset", "Content/Old Name.uasset", 'R', '.', false},
+		{`Source/"quoted".cpp`, "", '.', 'M', false},
+		{"Content/日本語/テスト.uasset", "", '?', '?', true},
+	}
+	for idx, test := range tests {
+		file := snapshot.Files[idx]
+		if file.Path != test.path || file.OrigPath != test.origPath || file.X != test.x || file.Y != test.y || file.Untracked != test.untracked {
+			t.Errorf("file %d: got %+v, want %+v", idx, file, test)
+		}
+	}
+	if !snapshot.Files[3].IsRename() {
+		t.Error("the rename should be a rename")
+	}
+}
+
+func TestGetAheadBehindWithoutUpstream(t *testing.T) {
+	repo, _ := replayRepo(t, "status_no_upstream.json")
+	_, _, err := GetAheadBehind(repo)
+	if err == nil {
+		t.Error("expected an error for a branch without upstream")
+	}
+}
+
+func TestRepoSnapshotDetached(t *testing.T) {
+	repo, _ := replayRepo(t, "status_detached.json")
+	snapshot, err := GetRepoSnapshot(repo, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapshot.DeatachedHead || snapshot.Branch != "" || snapshot.Head == "" {
+		t.Errorf("wrong detached snapshot: %+v", snapshot)
+	}
+}
+
+func TestRepoSnapshotConflict(t *testing.T) {
+	repo, _ := replayRepo(t, "status_conflict.json")
+	snapshot, err := GetRepoSnapshot(repo, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicted := snapshot.ConflictedFiles()
+	if !slices.Equal(conflicted, []string{"Content/Level 01.umap"}) {
+		t.Errorf("got %q", conflicted)
+	}
+}
+
+func TestParseStatusPorcelainV2Errors(t *testing.T) {
+	tests := map[string]string{
+		"no branch":        "1 .M N... 100644 100644 100644 abc abc file\x00",
+		"short line":       "# branch.head main\x001 .M N...\x00",
+		"rename at end":    "# branch.head main\x002 R. N... 100644 100644 100644 abc abc R100 new\x00",
+		"unknown record":   "# branch.head main\x00x what\x00",
+		"wrong XY in line": "# branch.head main\x001 M N... 100644 100644 100644 abc abc file\x00",
+	}
+	for name, out := range tests {
+		_, err := ParseStatusPorcelainV2(out)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
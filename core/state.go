@@ -0,0 +1,180 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Something git started and is waiting for us to finish or abort
+type GitOperation int
+
+const (
+	GIT_OPERATION_NONE GitOperation = iota
+	GIT_OPERATION_REBASE
+	GIT_OPERATION_MERGE
+	GIT_OPERATION_CHERRY_PICK
+	GIT_OPERATION_BISECT
+)
+
+func (operation GitOperation) String() string {
+	switch operation {
+	case GIT_OPERATION_NONE:
+		return "none"
+	case GIT_OPERATION_REBASE:
+		return "rebase"
+	case GIT_OPERATION_MERGE:
+		return "merge"
+	case GIT_OPERATION_CHERRY_PICK:
+		return "cherry-pick"
+	case GIT_OPERATION_BISECT:
+		return "bisect"
+	}
+	return "unknown"
+}
+
+// The files git leaves behind while an operation is underway, checked in this order.
+// rebase-apply is also used by git am, close enough for us.
+var gitOperationMarkers = []struct {
+	file      string
+	operation GitOperation
+}{
+	{"rebase-merge", GIT_OPERATION_REBASE},
+	{"rebase-apply", GIT_OPERATION_REBASE},
+	{"MERGE_HEAD", GIT_OPERATION_MERGE},
+	{"CHERRY_PICK_HEAD", GIT_OPERATION_CHERRY_PICK},
+	{"BISECT_LOG", GIT_OPERATION_BISECT},
+}
+
+// Absolute paths inside the git dir, --git-path takes care of worktrees and custom git dirs
+func GetGitPaths(repo *Repo, names ...string) ([]string, error) {
+	args := []string{"rev-parse"}
+	for _, name := range names {
+		args = append(args, "--git-path", name)
+	}
+	out, err := repo.executeStdout(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != len(names) {
+		return nil, errors.New("Unexpected output from git rev-parse --git-path")
+	}
+	for idx, path := range lines {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repo.Path, path)
+		}
+		lines[idx], err = filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// Looks at the files git keeps in .git instead of git status text, that one changes with the locale
+func GetOngoingOperation(repo *Repo) (GitOperation, error) {
+	names := make([]string, 0, len(gitOperationMarkers))
+	for _, marker := range gitOperationMarkers {
+		names = append(names, marker.file)
+	}
+
+	paths, err := GetGitPaths(repo, names...)
+	if err != nil {
+		return GIT_OPERATION_NONE, err
+	}
+
+	for idx, path := range paths {
+		if FileExists(path) {
+			return gitOperationMarkers[idx].operation, nil
+		}
+	}
+	return GIT_OPERATION_NONE, nil
+}
+
+// What aborting the ongoing operation would do to the user's work
+type AbortImpact struct {
+	Operation GitOperation
+	// Files whose current content, conflict resolutions included, gets thrown away
+	DiscardedFiles []string
+	// Changes git stashed when the operation started, they come back after aborting
+	HasAutostash bool
+	// Commits the rebase already replayed, they go back to how they were before
+	ReplayedCommits int
+}
+
+func GetAbortImpact(repo *Repo) (*AbortImpact, error) {
+	operation, err := GetOngoingOperation(repo)
+	if err != nil {
+		return nil, err
+	}
+	if operation == GIT_OPERATION_NONE {
+		return nil, errors.New("Nothing to abort")
+	}
+
+	impact := &AbortImpact{Operation: operation}
+
+	if operation != GIT_OPERATION_BISECT {
+		// bisect reset only moves HEAD back, changes in the working tree stay
+		snapshot, err := GetRepoSnapshot(repo, false)
+		if err != nil {
+			return nil, err
+		}
+		impact.DiscardedFiles = snapshot.FilePaths(true)
+	}
+
+	paths, err := GetGitPaths(repo, "rebase-merge/autostash", "rebase-apply/autostash", "MERGE_AUTOSTASH", "rebase-merge/done")
+	if err != nil {
+		return nil, err
+	}
+	impact.HasAutostash = FileExists(paths[0]) || FileExists(paths[1]) || FileExists(paths[2])
+
+	done, err := os.ReadFile(paths[3])
+	if err == nil {
+		for _, line := range strings.Split(string(done), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				impact.ReplayedCommits++
+			}
+		}
+		// the last one is where it stopped, it wasn't replayed yet
+		impact.ReplayedCommits = max(impact.ReplayedCommits-1, 0)
+	}
+
+	return impact, nil
+}
+
+// Gets out of a rebase, merge, cherry-pick or bisect back to where the user was before it started.
+// git puts back the autostash by itself when aborting.
+func AbortOperation(repo *Repo) error {
+	repo, unlock, err := repo.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	operation, err := GetOngoingOperation(repo)
+	if err != nil {
+		return err
+	}
+
+	if IsUnrealRunning() {
+		return errors.New("Unreal is running, close it before aborting")
+	}
+
+	switch operation {
+	case GIT_OPERATION_REBASE:
+		_, err = repo.executeOneLine("rebase", "--abort")
+	case GIT_OPERATION_MERGE:
+		_, err = repo.executeOneLine("merge", "--abort")
+	case GIT_OPERATION_CHERRY_PICK:
+		_, err = repo.executeOneLine("cherry-pick", "--abort")
+	case GIT_OPERATION_BISECT:
+		_, err = repo.executeOneLine("bisect", "reset")
+	default:
+		return errors.New("Nothing to abort")
+	}
+	return err
+}
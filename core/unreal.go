@@ -1,20 +1,42 @@
-package core
-
-import (
-	"strings"
-
-	"github.com/shirou/gopsutil/v3/process"
-)
-
-const UNREAL_PROCESS = "UnrealEditor"
-
-func IsUnrealRunning() bool {
-	allProcs, _ := process.Processes()
-	for _, proc := range allProcs {
-		procName, _ := proc.Name()
-		if strings.Contains(procName, UNREAL_PROCESS) {
-			return true
-		}
-	}
-	return false
-}
+package core
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const UNREAL_PROCESS = "UnrealEditor"
+
+func IsUnrealRunning() bool {
+	allProcs, _ := process.Processes()
+	for _, proc := range allProcs {
+		procName, _ := proc.Name()
+		if strings.Contains(procName, UNREAL_PROCESS) {
+			return true
+		}
+	}
+	return false
+}
+
+// The .uproject from the config if set, otherwise the first one in the repo root
+func FindUProject(repo *Repo) (string, error) {
+	if repo.Config.ProjectFile != "" {
+		projectFile := filepath.Join(repo.Path, repo.Config.ProjectFile)
+		if !FileExists(projectFile) {
+			return "", errors.New("Project file not found: " + projectFile)
+		}
+		return projectFile, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(repo.Path, "*.uproject"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", errors.New("No .uproject file found in " + repo.Path)
+	}
+	return matches[0], nil
+}
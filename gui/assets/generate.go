@@ -1,3 +1,3 @@
-package assets
-
-//go:generate fyne bundle --prefix Res --package assets -o bundled.go ./
+package assets
+
+//go:generate fyne bundle --prefix Res --package assets -o bundled.go ./
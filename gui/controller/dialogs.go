@@ -1,64 +1,113 @@
-package controller
-
-import (
-	"image/color"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/widget"
-)
-
-func CallFuncShowDialogOnError(f func() error) {
-	err := f()
-	if err != nil {
-		ShowErrorDialog(err)
-	}
-}
-
-func ShowErrorDialog(err error) {
-	if err == nil {
-		return
-	}
-	dialog.ShowError(err, GetApp().Window)
-}
-
-func ShowWarningDialog(title string, body string) {
-	dialog.ShowInformation(title, body, GetApp().Window)
-}
-
-func ShowLoadingDialog(title string) *dialog.CustomDialog {
-	bar := widget.NewProgressBarInfinite()
-	rect := canvas.NewRectangle(color.Transparent)
-	rect.SetMinSize(fyne.NewSize(200, 0))
-	stack := container.NewStack(rect, bar)
-	d := dialog.NewCustomWithoutButtons(title, stack, GetApp().Window)
-	d.Show()
-	return d
-}
-
-func ShowUsernameEmailDialog(provider string, callback func(string, string) error) {
-	usernameWidget := widget.NewEntry()
-	emailWidget := widget.NewEntry()
-	dialog.ShowForm(
-		"Enter the username and email for your "+provider+" account",
-		"OK",
-		"Cancel",
-		[]*widget.FormItem{ // we can specify items in the constructor
-			{Text: "Username", Widget: usernameWidget},
-			{Text: "Email", Widget: emailWidget},
-			{Text: "", Widget: layout.NewSpacer()},
-		},
-		func(ok bool) {
-			if !ok {
-				return
-			} else {
-				err := callback(usernameWidget.Text, emailWidget.Text)
-				if err != nil {
-					ShowErrorDialog(err)
-				}
-			}
-		}, GetApp().Window)
-}
+package controller
+
+import (
+	"errors"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+	"github.com/miltoncandelero/ugsg/gui/view"
+)
+
+func CallFuncShowDialogOnError(f func() error) {
+	err := f()
+	if err != nil {
+		ShowErrorDialog(err)
+	}
+}
+
+func ShowErrorDialog(err error) {
+	ShowErrorDialogWithFixes(err, nil)
+}
+
+// Errors we recognize get a plain explanation, the raw output under details and a button for the fix if there is one in fixes
+func ShowErrorDialogWithFixes(err error, fixes map[core.FixAction]func()) {
+	if err == nil {
+		return
+	}
+
+	var actionable *core.ActionableError
+	if !errors.As(core.ClassifyError(err), &actionable) {
+		dialog.ShowError(err, GetApp().Window)
+		return
+	}
+
+	explanation := widget.NewLabel(actionable.Explanation)
+	explanation.Wrapping = fyne.TextWrapWord
+	details := widget.NewLabel(actionable.Err.Error())
+	details.Wrapping = fyne.TextWrapWord
+	details.TextStyle = fyne.TextStyle{Monospace: true}
+	detailsAccordion := widget.NewAccordion(widget.NewAccordionItem("Details", container.NewVScroll(details)))
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(500, 0))
+
+	closeBtn := widget.NewButton("Close", nil)
+	buttons := container.NewHBox(layout.NewSpacer(), closeBtn)
+
+	content := container.NewBorder(container.NewStack(rect, explanation), buttons, nil, nil, detailsAccordion)
+	d := dialog.NewCustomWithoutButtons("Error", content, GetApp().Window)
+	closeBtn.OnTapped = d.Hide
+
+	fix := fixes[actionable.Fix]
+	if fix != nil {
+		fixBtn := widget.NewButton(actionable.Fix.Label(), func() {
+			d.Hide()
+			fix()
+		})
+		fixBtn.Importance = widget.HighImportance
+		buttons.Add(fixBtn)
+	}
+
+	d.Show()
+}
+
+func ShowWarningDialog(title string, body string) {
+	dialog.ShowInformation(title, body, GetApp().Window)
+}
+
+func ShowLoadingDialog(title string) *dialog.CustomDialog {
+	bar := widget.NewProgressBarInfinite()
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(200, 0))
+	stack := container.NewStack(rect, bar)
+	d := dialog.NewCustomWithoutButtons(title, stack, GetApp().Window)
+	d.Show()
+	return d
+}
+
+// Loading dialog with a Cancel button and room for progress, the caller has to be running its work off the UI for the button to work
+func ShowCancellableLoadingDialog(title string, cancel func()) *view.ProgressDialog {
+	d := view.MakeProgressDialog(title, cancel, GetApp().Window)
+	d.Show()
+	return d
+}
+
+func ShowUsernameEmailDialog(provider string, callback func(string, string) error) {
+	usernameWidget := widget.NewEntry()
+	emailWidget := widget.NewEntry()
+	dialog.ShowForm(
+		"Enter the username and email for your "+provider+" account",
+		"OK",
+		"Cancel",
+		[]*widget.FormItem{ // we can specify items in the constructor
+			{Text: "Username", Widget: usernameWidget},
+			{Text: "Email", Widget: emailWidget},
+			{Text: "", Widget: layout.NewSpacer()},
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			} else {
+				err := callback(usernameWidget.Text, emailWidget.Text)
+				if err != nil {
+					ShowErrorDialog(err)
+				}
+			}
+		}, GetApp().Window)
+}
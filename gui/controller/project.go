@@ -1,491 +1,1185 @@
-package controller
-
-import (
-	"errors"
-	"fmt"
-	"path/filepath"
-	"slices"
-	"strconv"
-	"strings"
-
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/theme"
-	"github.com/miltoncandelero/ugsg/core"
-	"github.com/miltoncandelero/ugsg/gui/assets"
-	"github.com/miltoncandelero/ugsg/gui/view"
-	"github.com/ncruces/zenity"
-	"github.com/skratchdot/open-golang/open"
-)
-
-type ProjectController struct {
-	ProjectStatus *view.ProjectStatus
-	CommitList    *view.CommitList
-	LockDialog    *view.LockedDialog
-	RepoPath      string
-}
-
-func UProjectOpened(uprojectPath string) {
-	d := ShowLoadingDialog("Opening...")
-	defer d.Hide()
-
-	repoPath := filepath.Dir(uprojectPath)
-	if !core.IsPathRepo(repoPath) {
-		// This is not a repo! panic
-
-		dialog.ShowError(fmt.Errorf("This is not a git repository"), GetApp().Window)
-		return
-	}
-
-	config := GetConfig()
-
-	foundIdx := slices.Index(config.RecentProjects, uprojectPath)
-	if foundIdx != -1 {
-		// Remove it from the list
-		config.RecentProjects = slices.Delete(config.RecentProjects, foundIdx, foundIdx+1)
-	}
-
-	config.RecentProjects = append([]string{uprojectPath}, config.RecentProjects...)
-	SaveConfig()
-
-	project := &ProjectController{RepoPath: repoPath}
-
-	project.ProjectStatus = view.MakeProjectStatus(uprojectPath)
-	// stuff that won't change goes here
-	project.ProjectStatus.ProjectTitle.Text = strings.ReplaceAll(filepath.Base(uprojectPath), ".uproject", "")
-	project.ProjectStatus.Subtitle.Text = uprojectPath
-	project.ProjectStatus.RefreshButtonCallback = project.refreshProject
-	project.ProjectStatus.ExploreButtonCallback = project.openInExplorer
-	project.ProjectStatus.TerminalButtonCallback = project.openInTerminal
-	project.ProjectStatus.PullButtonCallback = project.pull
-	project.ProjectStatus.SyncButtonCallback = project.sync
-
-	project.ProjectStatus.LockButtonCallback = project.manageLocks
-
-	project.ProjectStatus.RepoOrigin.SetText(core.GetRepoOrigin(repoPath))
-	switch core.GetGitProviderName(repoPath) {
-	case "GitHub":
-		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGithubSvg)
-	case "GitLab":
-		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGitlabSvg)
-	case "Gitea":
-		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGiteaSvg)
-	default:
-		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGitSvg)
-	}
-
-	project.CommitList = view.MakeCommitList(
-		project.checkoutCallback,
-		project.resetCallback,
-		GetApp().Window.Canvas(),
-	)
-
-	project.LockDialog = view.MakeLockedDialog(GetApp().Window)
-	project.LockDialog.UnlockFilesCalback = func(lockedFiles []core.LockDatum, force bool) {
-		d := ShowLoadingDialog("Unlocking...")
-		err := core.UnlockLFSFiles(project.RepoPath, lockedFiles, force)
-		d.Hide()
-		d = ShowLoadingDialog("Refreshing...")
-		project.refreshRepoStatus()
-		d.Hide()
-		if err != nil {
-			ShowErrorDialog(errors.New("Error unlocking. " + strconv.Itoa(len(err)) + " files couldn't be unlocked. Try with force?"))
-		}
-	}
-
-	project.LockDialog.RefreshCallback = func() {
-		d := ShowLoadingDialog("Refreshing...")
-		project.refreshRepoStatus()
-		d.Hide()
-	}
-
-	project.refreshProject()
-
-	mainVertical := container.NewBorder(project.ProjectStatus, nil, nil, nil, project.CommitList.Container)
-
-	appendProjectToMainWindow(mainVertical, uprojectPath)
-
-}
-
-func (project *ProjectController) checkoutCallback(hash string) {
-	defer project.refreshProject()
-
-	if core.GetWorkingTreeChangeAmount(project.RepoPath) > 0 {
-		ShowWarningDialog("I'm afraid I can't do that", "You have uncommited changes.\nPlease commit (or discard) them before trying to flashback")
-		return
-	}
-
-	d := ShowLoadingDialog("Flashing back...")
-
-	err := core.Checkout(project.RepoPath, hash)
-	if err != nil {
-		d.Hide()
-		ShowErrorDialog(err)
-		return
-	}
-	d.Hide()
-}
-
-func (project *ProjectController) resetCallback(hash string) {
-	defer project.refreshProject()
-
-	if core.GetWorkingTreeChangeAmount(project.RepoPath) > 0 {
-		ShowWarningDialog("I'm afraid I can't do that", "You have uncommited changes.\nPlease commit (or discard) them before trying to time travel")
-		return
-	}
-
-	ahead, _, _ := core.GetAheadBehind(project.RepoPath)
-	if ahead > 0 {
-		ShowWarningDialog("I'm afraid I can't do that", "You have commits that haven't been pushed. Please push your changes before trying to time travel")
-		return
-	}
-
-	d := ShowLoadingDialog("Time traveling...")
-	// cant defer close if I have dialogs :(
-
-	err := core.ResetHard(project.RepoPath, hash)
-	if err != nil {
-		d.Hide()
-		ShowErrorDialog(err)
-		return
-	}
-
-	d.Hide()
-}
-
-func (project *ProjectController) openInExplorer() {
-	open.Run(project.RepoPath)
-}
-
-func (project *ProjectController) openInTerminal() {
-	core.OpenCmd(project.RepoPath)
-}
-
-func (project *ProjectController) pull() {
-
-	defer project.refreshProject()
-	d := ShowLoadingDialog("Pulling...")
-	if core.GetGitStatus(project.RepoPath) != core.GIT_STATUS_OK {
-		d.Hide()
-		ShowErrorDialog(fmt.Errorf("Repo not ok. Can't pull"))
-		return
-	}
-	err := core.GitSmartPull(project.RepoPath)
-	if err != nil {
-		d.Hide()
-		ShowErrorDialog(err)
-		return
-	}
-
-	d.Hide()
-}
-
-func (project *ProjectController) sync() {
-	defer project.refreshProject()
-	d := ShowLoadingDialog("Syncing...")
-	if core.GetGitStatus(project.RepoPath) != core.GIT_STATUS_OK {
-		d.Hide()
-		ShowErrorDialog(fmt.Errorf("Repo not ok. Can't sync"))
-		return
-	}
-	err := core.GitSmartPull(project.RepoPath)
-	if err != nil {
-		d.Hide()
-		ShowErrorDialog(err)
-		return
-	}
-	err = core.GitPush(project.RepoPath)
-	if err != nil {
-		d.Hide()
-		ShowErrorDialog(err)
-		return
-	}
-	d.Hide()
-}
-
-func (project *ProjectController) manageLocks() {
-
-	// defer project.refreshProject()
-	// if core.GetGitStatus(project.RepoPath) != core.GIT_STATUS_OK {
-	// 	ShowErrorDialog(fmt.Errorf("Repo not ok. Can't commit"))
-	// 	return
-	// }
-	//dialog.ShowInformation("Not implemented", "Not implemented yet :P", GetApp().Window)
-	project.LockDialog.Show()
-}
-
-func (project *ProjectController) refreshProject() {
-	d := ShowLoadingDialog("Refreshing...")
-	defer d.Hide()
-
-	project.refreshRepo()
-	// refresh build
-	// reresh other stuff?
-}
-
-func (project *ProjectController) refreshRepo() {
-	project.refreshRepoStatus()
-	project.refreshRepoUserData()
-	project.refreshRepoConfigStatus()
-	project.refreshRepoActions()
-	project.refreshCommits()
-}
-
-func (project *ProjectController) refreshRepoUserData() {
-	if core.NeedsUsernameFix(project.RepoPath) {
-		project.ProjectStatus.RepoUser.SetText("Username missing!")
-		project.ProjectStatus.RepoUser.SetIcon(theme.ErrorIcon())
-		project.ProjectStatus.RepoUser.SetColor(theme.ColorNameError)
-		project.ProjectStatus.FixUserLink.SetText("Fix")
-	} else {
-		project.ProjectStatus.RepoUser.SetText(core.GetUsernameFromRepo(project.RepoPath) + " (" + core.GetUserEmailFromRepo(project.RepoPath) + ")")
-		project.ProjectStatus.RepoUser.SetIcon(theme.AccountIcon())
-		project.ProjectStatus.RepoUser.SetColor(theme.ColorNameForeground)
-		project.ProjectStatus.FixUserLink.SetText("Change")
-	}
-	project.ProjectStatus.FixUserLinkCallback = func() {
-		ShowUsernameEmailDialog(core.GetGitProviderName(project.RepoPath),
-			func(username string, email string) error {
-				err := core.SetUsernameAndEmail(project.RepoPath, username, email)
-				if err != nil {
-					return err
-				}
-				project.refreshRepo()
-				return nil
-			})
-	}
-}
-
-func (project *ProjectController) refreshRepoConfigStatus() {
-	switch core.GetGitConfigStatus(project.RepoPath) {
-	case core.CONFIG_STATUS_MISSING:
-		project.ProjectStatus.ConfigStatus.SetText(".gitconfig missing")
-		project.ProjectStatus.ConfigStatus.SetColor(theme.ColorNameWarning)
-		project.ProjectStatus.ConfigStatus.SetIcon(theme.QuestionIcon())
-		project.ProjectStatus.FixConfigLink.SetText("Create")
-		project.ProjectStatus.FixConfigLink.Show()
-		project.ProjectStatus.FixConfigLinkCallback = func() {
-			err := core.CreateGitConfig(project.RepoPath)
-			if err != nil {
-				ShowErrorDialog(err)
-			}
-			project.refreshRepoConfigStatus()
-		}
-	case core.CONFIG_STATUS_NOT_LINKED:
-		project.ProjectStatus.ConfigStatus.SetText(".gitconfig found but not installed!")
-		project.ProjectStatus.ConfigStatus.SetColor(theme.ColorNameError)
-		project.ProjectStatus.ConfigStatus.SetIcon(theme.ErrorIcon())
-		project.ProjectStatus.FixConfigLink.SetText("Fix")
-		project.ProjectStatus.FixConfigLink.Show()
-		project.ProjectStatus.FixConfigLinkCallback = func() {
-			err := core.LinkGitConfig(project.RepoPath)
-			if err != nil {
-				ShowErrorDialog(err)
-			}
-			project.refreshRepoConfigStatus()
-		}
-	case core.CONFIG_STATUS_LINKED:
-		project.ProjectStatus.ConfigStatus.SetText(".gitconfig linked")
-		project.ProjectStatus.ConfigStatus.SetColor(theme.ColorNameSuccess)
-		project.ProjectStatus.ConfigStatus.SetIcon(theme.ConfirmIcon())
-		project.ProjectStatus.FixConfigLink.Hide()
-	}
-}
-
-func (project *ProjectController) refreshRepoStatus() {
-	status := core.GetGitStatus(project.RepoPath)
-	switch status {
-	case core.GIT_STATUS_OK:
-		project.ProjectStatus.RepoStatus.SetText("Repo ok")
-		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameSuccess)
-		project.ProjectStatus.RepoStatus.SetIcon(theme.ConfirmIcon())
-		project.ProjectStatus.FixRepoStatusLink.Hide()
-	case core.GIT_STATUS_SHALLOW:
-		project.ProjectStatus.RepoStatus.SetText("Repo is shallow!")
-		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
-		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
-		project.ProjectStatus.FixRepoStatusLink.SetText("unshallow")
-		project.ProjectStatus.FixRepoStatusLink.Show()
-		project.ProjectStatus.FixRepoStatusCallback = func() {
-			defer project.refreshRepo()
-			d := ShowLoadingDialog("Unshallowing (This will take a while)...")
-			err := core.UnshallowRepo(project.RepoPath)
-			if err != nil {
-				d.Hide()
-				ShowErrorDialog(err)
-				return
-			}
-			d.Hide()
-		}
-	case core.GIT_STATUS_REBASE_CONTINUABLE:
-		project.ProjectStatus.RepoStatus.SetText("Rebase underway, ready to continue")
-		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameForeground)
-		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
-		project.ProjectStatus.FixRepoStatusLink.SetText("continue")
-		project.ProjectStatus.FixRepoStatusLink.Show()
-		project.ProjectStatus.FixRepoStatusCallback = func() {
-			defer project.refreshRepo()
-			d := ShowLoadingDialog("Rebasing...")
-			err := core.FinishRebase(project.RepoPath)
-			if err != nil {
-				d.Hide()
-				ShowErrorDialog(err)
-				return
-			}
-			d.Hide()
-		}
-	case core.GIT_STATUS_REBASE_CONFLICTS:
-		project.ProjectStatus.RepoStatus.SetText("Rebase underway, conflicts detected!")
-		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameError)
-		project.ProjectStatus.RepoStatus.SetIcon(theme.ErrorIcon())
-		project.ProjectStatus.FixRepoStatusLink.SetText("continue")
-		project.ProjectStatus.FixRepoStatusLink.Show()
-		project.ProjectStatus.FixRepoStatusCallback = func() {
-			defer project.refreshRepo()
-			d := ShowLoadingDialog("Rebasing...")
-			err := core.FinishRebase(project.RepoPath)
-			if err != nil {
-				d.Hide()
-				ShowErrorDialog(err)
-				return
-			}
-			d.Hide()
-		}
-	case core.GIT_STATUS_LAST_COMMIT_MERGE:
-		project.ProjectStatus.RepoStatus.SetText("Merge commit detected! This shouldn't have happened!")
-		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
-		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
-		project.ProjectStatus.FixRepoStatusLink.Hide()
-	case core.GIT_STATUS_DEATACHED_HEAD:
-		project.ProjectStatus.RepoStatus.SetText("Currently in a Flashback (Deatached HEAD)")
-		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
-		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
-		project.ProjectStatus.FixRepoStatusLink.SetText("end Flashback")
-		project.ProjectStatus.FixRepoStatusCallback = func() {
-			defer project.refreshRepo()
-			d := ShowLoadingDialog("Returning...")
-			err := core.ReturnToLastBranch(project.RepoPath)
-			if err != nil {
-				d.Hide()
-				ShowErrorDialog(err)
-				return
-			}
-			d.Hide()
-		}
-		project.ProjectStatus.FixRepoStatusLink.Show()
-	}
-
-	if core.IsDeatachedHead(project.RepoPath) {
-		project.ProjectStatus.RepoBranch.SetText("in a Flashback")
-		project.ProjectStatus.RepoBranch.SetIcon(theme.WarningIcon())
-		project.ProjectStatus.RepoAhead.Hide()
-		project.ProjectStatus.RepoBehind.Hide()
-		project.ProjectStatus.RepoWorkingTree.Hide()
-		project.ProjectStatus.RepoLockedFiles.Hide()
-	} else {
-
-		ahead, behind, _ := core.GetAheadBehind(project.RepoPath)
-		project.ProjectStatus.RepoAhead.SetText(strconv.Itoa(ahead))
-		project.ProjectStatus.RepoBehind.SetText(strconv.Itoa(behind))
-		project.ProjectStatus.RepoAhead.Show()
-		project.ProjectStatus.RepoBehind.Show()
-
-		changes := core.GetWorkingTreeChangeAmount(project.RepoPath)
-		if changes == 0 {
-			project.ProjectStatus.RepoWorkingTree.Hide()
-		} else {
-			project.ProjectStatus.RepoWorkingTree.Show()
-			project.ProjectStatus.RepoWorkingTree.SetText(strconv.Itoa(changes))
-		}
-
-		branch, _ := core.GetCurrentBranchFromRepository(project.RepoPath)
-
-		project.ProjectStatus.RepoBranch.SetText(branch)
-		project.ProjectStatus.RepoBranch.SetIcon(assets.ResBranchSvg)
-
-		lockedFiles, _ := core.GetLockedFiles(project.RepoPath, core.GetUsernameFromRepo(project.RepoPath))
-		if len(lockedFiles) == 0 {
-			project.ProjectStatus.RepoLockedFiles.Hide()
-			project.LockDialog.UpdateData(lockedFiles, lockedFiles)
-			project.ProjectStatus.LockButton.Hide()
-		} else {
-			project.ProjectStatus.RepoLockedFiles.Show()
-			project.ProjectStatus.RepoLockedFiles.SetText(strconv.Itoa(len(lockedFiles)))
-			unchangedLocked, _ := core.ListLFSLockedUnchangedFiles(project.RepoPath)
-			project.LockDialog.UpdateData(lockedFiles, unchangedLocked)
-			project.ProjectStatus.LockButton.Show()
-		}
-	}
-}
-
-func (project *ProjectController) refreshRepoActions() {
-	if core.GetGitStatus(project.RepoPath) != core.GIT_STATUS_OK {
-		project.ProjectStatus.PullButton.Disable()
-		project.ProjectStatus.SyncButton.Disable()
-		// project.ProjectStatus.CommitButton.Disable()
-
-		project.ProjectStatus.PullButton.SetText("Repo not ok. Can't pull")
-		project.ProjectStatus.SyncButton.SetText("Repo not ok. Can't sync")
-		// project.ProjectStatus.CommitButton.SetText("Repo not ok. Can't commit")
-		return
-	}
-
-	// if core.GetWorkingTreeChangeAmount(project.RepoPath) > 0 {
-	// 	project.ProjectStatus.CommitButton.SetText("Commit")
-	// 	project.ProjectStatus.CommitButton.Enable()
-	// } else {
-	// 	project.ProjectStatus.CommitButton.SetText("Nothing to commit")
-	// 	project.ProjectStatus.CommitButton.Disable()
-	// }
-
-	ahead, behind, _ := core.GetAheadBehind(project.RepoPath)
-	if behind > 0 {
-		project.ProjectStatus.PullButton.SetText("Pull")
-		project.ProjectStatus.PullButton.Enable()
-	} else {
-		project.ProjectStatus.PullButton.SetText("Nothing to pull")
-		project.ProjectStatus.PullButton.Disable()
-	}
-
-	if behind > 0 || ahead > 0 {
-		project.ProjectStatus.SyncButton.SetText("Sync")
-		project.ProjectStatus.SyncButton.Enable()
-	} else {
-		project.ProjectStatus.SyncButton.SetText("Nothing to sync")
-		project.ProjectStatus.SyncButton.Disable()
-	}
-
-}
-
-func (project *ProjectController) refreshCommits() {
-	commits, _ := core.GetRepoBranchInfo(project.RepoPath, "")
-	project.CommitList.UpdateCommits(commits)
-}
-
-func openFilePickerUproject() {
-	file, err := zenity.SelectFile(
-		zenity.Filename("./"),
-		zenity.Title("Select Unreal Engine Project file"),
-		zenity.Modal(),
-		zenity.FileFilters{
-			{
-				Name:     "Unreal Engine Project files",
-				Patterns: []string{"*.uproject"},
-				CaseFold: false,
-			},
-		},
-	)
-	if err == nil {
-		UProjectOpened(file)
-	}
-}
-
-func ForgetProject(projectFile string) {
-	config := GetConfig()
-	foundIdx := slices.Index(config.RecentProjects, projectFile)
-	if foundIdx != -1 {
-		// Remove it from the list
-		config.RecentProjects = slices.Delete(config.RecentProjects, foundIdx, foundIdx+1)
-	}
-	SaveConfig()
-}
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+	"github.com/miltoncandelero/ugsg/gui/assets"
+	"github.com/miltoncandelero/ugsg/gui/model"
+	"github.com/miltoncandelero/ugsg/gui/view"
+	"github.com/ncruces/zenity"
+	"github.com/skratchdot/open-golang/open"
+)
+
+// Longest list of files we put in a dialog before saying "and N more"
+const MAX_LISTED_FILES = 15
+
+type ProjectController struct {
+	ProjectStatus *view.ProjectStatus
+	CommitList    *view.CommitList
+	LockDialog    *view.LockedDialog
+	CommitDialog  *view.CommitDialog
+	Conflicts     *view.ConflictDialog
+	Linearize     *view.LinearizeDialog
+	Backups       *view.BackupsDialog
+	Settings      *view.SettingsDialog
+	// swapped whole when the settings change, workers keep the one they started with
+	repo atomic.Pointer[core.Repo]
+
+	// each one loads on its own worker and lands on the views through a binding
+	status  *refreshSection
+	locks   *refreshSection
+	commits *refreshSection
+
+	// selectedBranch and locksErr are written by the binding listeners and read from the workers
+	stateMutex sync.Mutex
+	// Branch shown in the commit list, HEAD is whatever is checked out
+	selectedBranch string
+	// why the last locks refresh failed, shown instead of an empty lock dialog
+	locksErr error
+
+	// a refresh while a fetch is running waits for that one instead of starting another
+	fetching atomic.Bool
+	// cancelled when the tab is closed, stops the background work of the project
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	Console *view.Console
+	// commands come in from any goroutine, they are batched before reaching the console
+	consoleEvents         []core.CommandEvent
+	consoleFlushScheduled bool
+	consoleMutex          sync.Mutex
+	stopConsole           func()
+}
+
+// How long command output is batched before the console is redrawn
+const CONSOLE_FLUSH_INTERVAL = 250 * time.Millisecond
+
+func UProjectOpened(uprojectPath string) {
+	openProject(uprojectPath, core.OpenRepo(filepath.Dir(uprojectPath)))
+}
+
+func openProject(uprojectPath string, repo *core.Repo) {
+	d := ShowLoadingDialog("Opening...")
+	defer d.Hide()
+
+	err := core.CheckRepo(repo)
+	if err != nil {
+		ShowErrorDialog(err)
+		return
+	}
+
+	if !isRunningFromInsideProject() {
+		config := GetConfig()
+
+		foundIdx := slices.Index(config.RecentProjects, uprojectPath)
+		if foundIdx != -1 {
+			// Remove it from the list
+			config.RecentProjects = slices.Delete(config.RecentProjects, foundIdx, foundIdx+1)
+		}
+
+		config.RecentProjects = append([]string{uprojectPath}, config.RecentProjects...)
+		SaveConfig()
+	}
+
+	project := &ProjectController{selectedBranch: "HEAD"}
+	project.repo.Store(repo)
+	project.ctx, project.cancel = context.WithCancel(context.Background())
+
+	project.ProjectStatus = view.MakeProjectStatus(uprojectPath)
+	// stuff that won't change goes here
+	project.ProjectStatus.ProjectTitle.Text = strings.ReplaceAll(filepath.Base(uprojectPath), ".uproject", "")
+	project.ProjectStatus.Subtitle.Text = uprojectPath
+	project.ProjectStatus.RefreshButtonCallback = project.refreshProject
+	project.ProjectStatus.ExploreButtonCallback = project.openInExplorer
+	project.ProjectStatus.TerminalButtonCallback = project.openInTerminal
+	project.ProjectStatus.SettingsButtonCallback = project.openSettings
+	project.ProjectStatus.UndoButtonCallback = project.openBackups
+	project.ProjectStatus.PullButtonCallback = project.pull
+	project.ProjectStatus.SyncButtonCallback = project.sync
+	project.ProjectStatus.CommitButtonCallback = project.commit
+
+	project.ProjectStatus.LockButtonCallback = project.manageLocks
+
+	origin, err := core.GetRepoOrigin(repo)
+	if err != nil {
+		origin = "No remote " + repo.Config.RemoteName
+		project.ProjectStatus.RepoOrigin.SetColor(theme.ColorNameWarning)
+	}
+	project.ProjectStatus.RepoOrigin.SetText(origin)
+	provider, _ := core.GetGitProviderName(repo)
+	switch provider {
+	case "GitHub":
+		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGithubSvg)
+	case "GitLab":
+		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGitlabSvg)
+	case "Gitea":
+		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGiteaSvg)
+	default:
+		project.ProjectStatus.RepoOrigin.SetIcon(assets.ResGitSvg)
+	}
+
+	project.CommitList = view.MakeCommitList(
+		project.checkoutCallback,
+		project.resetCallback,
+		project.loadMoreCommits,
+		project.branchSelected,
+		GetApp().Window.Canvas(),
+	)
+
+	project.LockDialog = view.MakeLockedDialog(GetApp().Window)
+	project.LockDialog.UnlockFilesCalback = func(lockedFiles []core.LockDatum, force bool) {
+		project.runCancellable("Unlocking...", func(repo *core.Repo) error {
+			return project.runJournaled(repo, "unlock", core.UnlockStep(lockedFiles, force))
+		}, func(err error) {
+			project.refreshLocks()
+			if err != nil {
+				project.checkInterruptedJournal()
+				project.showError(fmt.Errorf("Error unlocking. %w. Try with force?", err))
+			}
+		})
+	}
+
+	project.LockDialog.RefreshCallback = project.refreshLocks
+
+	project.CommitDialog = view.MakeCommitDialog(GetApp().Window)
+	project.CommitDialog.CommitCallback = func(files []string, message string) {
+		project.runCancellable("Committing...", func(repo *core.Repo) error {
+			return core.Commit(repo, files, message)
+		}, func(err error) {
+			if err != nil {
+				project.showError(err)
+				return
+			}
+			project.CommitDialog.ClearMessage()
+			project.CommitDialog.Hide()
+			project.refreshProject()
+		})
+	}
+
+	project.Conflicts = view.MakeConflictDialog(GetApp().Window)
+	project.Conflicts.ResolveCallback = project.resolveConflicts
+	project.Conflicts.AbortCallback = project.abortOperation
+	project.ProjectStatus.AbortRepoStatusCallback = project.abortOperation
+
+	project.Linearize = view.MakeLinearizeDialog(GetApp().Window)
+	project.Linearize.LinearizeCallback = project.linearize
+
+	project.Backups = view.MakeBackupsDialog(GetApp().Window)
+	project.Backups.RestoreCallback = project.restoreBackup
+
+	project.Settings = view.MakeSettingsDialog(GetApp().Window)
+	project.Settings.SaveCallback = project.saveSettings
+
+	project.status = makeRefreshSection(project.ProjectStatus.StatusSpinner, project.applyStatus)
+	project.locks = makeRefreshSection(project.ProjectStatus.LocksSpinner, project.applyLocks)
+	project.commits = makeRefreshSection(project.CommitList.Spinner, project.applyCommits)
+
+	// before the first refresh so its commands show up
+	project.Console = view.MakeConsole(GetApp().Window)
+	project.listenToCommands()
+
+	project.refreshProject()
+	project.checkInterruptedJournal()
+
+	mainVertical := container.NewBorder(project.ProjectStatus, project.Console.Container, nil, nil, project.CommitList.Container)
+
+	appendProjectToMainWindow(mainVertical, uprojectPath, func() {
+		project.cancel()
+		project.stopConsole()
+	})
+
+}
+
+func (project *ProjectController) checkoutCallback(hash string) {
+	changes, err := core.GetWorkingTreeChangeAmount(project.Repo())
+	if err != nil {
+		project.showError(err)
+		return
+	}
+	if changes > 0 {
+		ShowWarningDialog("I'm afraid I can't do that", "You have uncommited changes.\nPlease commit (or discard) them before trying to flashback")
+		return
+	}
+
+	project.runCancellable("Flashing back...", func(repo *core.Repo) error {
+		return project.runJournaled(repo, "flashback", core.CheckoutStep(hash))
+	}, project.journaledDone)
+}
+
+func (project *ProjectController) resetCallback(hash string) {
+	snapshot, err := core.GetRepoSnapshot(project.Repo(), true)
+	if err != nil {
+		project.showError(err)
+		return
+	}
+
+	if snapshot.ChangeAmount() > 0 {
+		ShowWarningDialog("I'm afraid I can't do that", "You have uncommited changes.\nPlease commit (or discard) them before trying to time travel")
+		return
+	}
+
+	if snapshot.Ahead > 0 {
+		ShowWarningDialog("I'm afraid I can't do that", "You have commits that haven't been pushed. Please push your changes before trying to time travel")
+		return
+	}
+
+	project.runCancellable("Time traveling...", func(repo *core.Repo) error {
+		return project.runJournaled(repo, "time travel", core.ResetStep(hash))
+	}, project.journaledDone)
+}
+
+// Error dialog with buttons for the fixes this project can do. Cancelling is the user's choice, not something to complain about
+func (project *ProjectController) showError(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	ShowErrorDialogWithFixes(err, map[core.FixAction]func(){
+		core.FIX_ACTION_PULL:              project.pull,
+		core.FIX_ACTION_MANAGE_LOCKS:      project.manageLocks,
+		core.FIX_ACTION_REMOVE_INDEX_LOCK: project.removeStaleIndexLock,
+		core.FIX_ACTION_PRUNE_LFS:         project.pruneLFS,
+		core.FIX_ACTION_OPEN_TERMINAL:     project.openInTerminal,
+	})
+}
+
+// Sends every command run on this repo to the console
+func (project *ProjectController) listenToCommands() {
+	repoPath := project.Repo().Path
+	project.stopConsole = core.AddCommandListener(func(event core.CommandEvent) {
+		if event.WorkingDir != repoPath {
+			return
+		}
+		project.queueConsoleEvent(event)
+	})
+}
+
+func (project *ProjectController) queueConsoleEvent(event core.CommandEvent) {
+	project.consoleMutex.Lock()
+	defer project.consoleMutex.Unlock()
+	project.consoleEvents = append(project.consoleEvents, event)
+	if !project.consoleFlushScheduled {
+		project.consoleFlushScheduled = true
+		time.AfterFunc(CONSOLE_FLUSH_INTERVAL, project.flushConsole)
+	}
+}
+
+// For failures of the background refreshes: they run again and again while offline, a dialog each time would be unbearable
+func (project *ProjectController) logError(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	project.queueConsoleEvent(core.CommandEvent{
+		Kind:       core.COMMAND_STDERR,
+		Time:       time.Now(),
+		WorkingDir: project.Repo().Path,
+		Line:       "ugsg: " + err.Error(),
+	})
+}
+
+func (project *ProjectController) flushConsole() {
+	project.consoleMutex.Lock()
+	events := project.consoleEvents
+	project.consoleEvents = nil
+	project.consoleFlushScheduled = false
+	project.consoleMutex.Unlock()
+
+	project.Console.AddEvents(events)
+}
+
+// Multi step operations go through the journal so closing the app halfway can be recovered from
+func (project *ProjectController) runJournaled(repo *core.Repo, operation string, steps ...core.JournalStep) error {
+	journal, err := core.StartJournal(repo, operation, steps...)
+	if err != nil {
+		return err
+	}
+	return core.RunJournal(repo, journal)
+}
+
+// For onDone of journaled operations: a failed or cancelled one leaves its journal behind, offer to resume or roll it back.
+// The error goes on top so it is read first.
+func (project *ProjectController) journaledDone(err error) {
+	project.refreshProject()
+	if err != nil {
+		project.checkInterruptedJournal()
+	}
+	project.showError(err)
+}
+
+// Runs the operation off the UI with a Cancel button that kills whatever git is doing at the moment, showing its progress.
+// onDone gets the error (a cancelled one wraps context.Canceled) once the loading dialog is gone.
+func (project *ProjectController) runCancellable(title string, operation func(repo *core.Repo) error, onDone func(error)) {
+	// closing the tab stops it too
+	ctx, cancel := context.WithCancel(project.ctx)
+	d := ShowCancellableLoadingDialog(title, cancel)
+	go func() {
+		defer cancel()
+		err := operation(project.Repo().WithContext(ctx).WithProgress(d.UpdateProgress))
+		d.Hide()
+		if project.ctx.Err() != nil {
+			// the tab is gone. A journaled operation offers to resume when the project is opened again
+			return
+		}
+		onDone(err)
+	}()
+}
+
+func (project *ProjectController) checkInterruptedJournal() {
+	journal, err := core.LoadJournal(project.Repo())
+	if err != nil {
+		project.showError(fmt.Errorf("Could not read the journal of the last operation: %w", err))
+		return
+	}
+	if journal == nil {
+		return
+	}
+
+	message := widget.NewLabel("The last " + journal.Operation + " started " + journal.StartedAt.Format("2006-01-02 15:04") + " didn't finish, it failed, was cancelled or the app was closed halfway.\n\n" +
+		journal.Describe() + "\n\nResume runs what is left. Roll back goes back to where you were before it started, pushes and unlocks already done stay done.")
+	message.Wrapping = fyne.TextWrapWord
+
+	var d *dialog.CustomDialog
+	finish := func(loadingText string, action func(repo *core.Repo) error) {
+		d.Hide()
+		project.runCancellable(loadingText, action, project.journaledDone)
+	}
+	ignoreBtn := widget.NewButton("Forget about it", func() {
+		finish("Forgetting...", func(_ *core.Repo) error { return journal.Finish() })
+	})
+	rollbackBtn := widget.NewButton("Roll back", func() {
+		finish("Rolling back...", func(repo *core.Repo) error { return core.RollbackJournal(repo, journal) })
+	})
+	resumeBtn := widget.NewButton("Resume", func() {
+		finish("Resuming...", func(repo *core.Repo) error { return core.RunJournal(repo, journal) })
+	})
+	resumeBtn.Importance = widget.HighImportance
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(500, 0))
+	content := container.NewBorder(nil, container.NewHBox(layout.NewSpacer(), ignoreBtn, rollbackBtn, resumeBtn), nil, nil, container.NewStack(rect, message))
+	d = dialog.NewCustomWithoutButtons("Interrupted "+journal.Operation, content, GetApp().Window)
+	d.Show()
+}
+
+func (project *ProjectController) openInExplorer() {
+	open.Run(project.Repo().Path)
+}
+
+func (project *ProjectController) openInTerminal() {
+	core.OpenCmd(project.Repo().Path)
+}
+
+func checkRepoOk(repo *core.Repo, action string) error {
+	status, err := core.GetGitStatus(repo)
+	if err != nil {
+		return err
+	}
+	if status != core.GIT_STATUS_OK {
+		return fmt.Errorf("Repo not ok. Can't %s", action)
+	}
+	return nil
+}
+
+func (project *ProjectController) pull() {
+	project.runCancellable("Pulling...", func(repo *core.Repo) error {
+		err := checkRepoOk(repo, "pull")
+		if err != nil {
+			return err
+		}
+		return project.runJournaled(repo, "pull", core.PullStep())
+	}, project.journaledDone)
+}
+
+func (project *ProjectController) sync() {
+	project.runCancellable("Syncing...", func(repo *core.Repo) error {
+		err := checkRepoOk(repo, "sync")
+		if err != nil {
+			return err
+		}
+		return project.runJournaled(repo, "sync", core.PullStep(), core.PushStep())
+	}, project.journaledDone)
+}
+
+func (project *ProjectController) commit() {
+	err := checkRepoOk(project.Repo(), "commit")
+	if err != nil {
+		project.showError(err)
+		return
+	}
+
+	d := ShowLoadingDialog("Looking for changes...")
+	files, err := core.GetWorkingTreeFiles(project.Repo(), false)
+	if err != nil {
+		d.Hide()
+		project.showError(err)
+		return
+	}
+	missingLock := make([]string, 0)
+	if project.Repo().Config.RequireLocksToCommit {
+		missingLock, err = core.GetFilesMissingLock(project.Repo(), files)
+		if err != nil {
+			d.Hide()
+			project.showError(err)
+			return
+		}
+	}
+	d.Hide()
+
+	project.CommitDialog.UpdateData(files, missingLock)
+	project.CommitDialog.Show()
+}
+
+func (project *ProjectController) showConflicts() {
+	d := ShowLoadingDialog("Looking for conflicts...")
+	conflicts, err := core.GetRebaseConflicts(project.Repo())
+	d.Hide()
+	if err != nil {
+		project.showError(err)
+		return
+	}
+
+	project.Conflicts.UpdateData(conflicts)
+	project.Conflicts.Show()
+}
+
+func (project *ProjectController) resolveConflicts(conflicts []core.ConflictDatum, resolutions map[string]core.ConflictResolution) {
+	var remaining []core.ConflictDatum
+	finishing := false
+	project.runCancellable("Resolving...", func(repo *core.Repo) error {
+		err := core.ResolveConflicts(repo, conflicts, resolutions)
+		if err != nil {
+			return err
+		}
+		remaining, err = core.GetRebaseConflicts(repo)
+		if err != nil || len(remaining) > 0 {
+			return err
+		}
+		finishing = true
+		return core.FinishRebase(repo)
+	}, func(err error) {
+		defer project.refreshRepo()
+		if err != nil && finishing {
+			if status, _ := core.GetGitStatus(project.Repo()); status == core.GIT_STATUS_REBASE_CONFLICTS {
+				// the next commit in line conflicts too
+				project.showConflicts()
+				ShowWarningDialog("More conflicts", "Another one of your commits changed files someone else changed too")
+				return
+			}
+			project.Conflicts.Hide()
+		}
+		if err != nil {
+			project.showError(err)
+			return
+		}
+		if len(remaining) > 0 {
+			project.Conflicts.UpdateData(remaining)
+			ShowWarningDialog("Not done yet", "Pick a version for every file before continuing")
+			return
+		}
+		project.Conflicts.Hide()
+	})
+}
+
+func (project *ProjectController) abortOperation() {
+	d := ShowLoadingDialog("Checking what would be lost...")
+	impact, err := core.GetAbortImpact(project.Repo())
+	d.Hide()
+	if err != nil {
+		project.showError(err)
+		project.refreshStatus()
+		return
+	}
+
+	message := "Everything goes back to how it was before the " + impact.Operation.String() + " started."
+	if impact.ReplayedCommits > 0 {
+		message += "\n\n" + strconv.Itoa(impact.ReplayedCommits) + " of your commits were already replayed, they go back to how they were."
+	}
+	if len(impact.DiscardedFiles) > 0 {
+		message += "\n\nThese files lose their current changes (conflicts you resolved included):"
+		for idx, file := range impact.DiscardedFiles {
+			if idx == MAX_LISTED_FILES {
+				message += "\n  ...and " + strconv.Itoa(len(impact.DiscardedFiles)-idx) + " more"
+				break
+			}
+			message += "\n  " + file
+		}
+	}
+	if impact.HasAutostash {
+		message += "\n\nThe uncommitted changes you had before it started come back."
+	}
+
+	dialog.ShowConfirm("Abort "+impact.Operation.String()+"?", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		project.runCancellable("Aborting...", core.AbortOperation, func(err error) {
+			defer project.refreshRepo()
+			if err != nil {
+				project.showError(err)
+				return
+			}
+			project.Conflicts.Hide()
+		})
+	}, GetApp().Window)
+}
+
+func (project *ProjectController) previewLinearize() {
+	d := ShowLoadingDialog("Looking at your commits...")
+	commits, err := core.PreviewLinearize(project.Repo())
+	d.Hide()
+	if err != nil {
+		project.showError(err)
+		return
+	}
+
+	project.Linearize.UpdateData(commits)
+	project.Linearize.Show()
+}
+
+func (project *ProjectController) linearize() {
+	project.Linearize.Hide()
+	project.runCancellable("Fixing history...", core.Linearize, func(err error) {
+		project.refreshProject()
+		project.showError(err)
+	})
+}
+
+func (project *ProjectController) openBackups() {
+	backups, err := core.GetBackups(project.Repo())
+	if err != nil {
+		project.showError(err)
+		return
+	}
+
+	project.Backups.UpdateData(backups)
+	project.Backups.Show()
+}
+
+func (project *ProjectController) restoreBackup(backup core.BackupDatum) {
+	branch := backup.Branch
+	if branch == "HEAD" {
+		branch = "a Flashback"
+	}
+	dialog.ShowConfirm("Go back?", "You will be on "+branch+" at \""+backup.Msg+"\" again.\nWhere you are now gets a backup too, so you can come back.", func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		project.runCancellable("Going back...", func(repo *core.Repo) error {
+			return core.RestoreBackup(repo, backup)
+		}, func(err error) {
+			defer project.refreshProject()
+			if err != nil {
+				project.showError(err)
+				return
+			}
+			project.Backups.Hide()
+		})
+	}, GetApp().Window)
+}
+
+func (project *ProjectController) removeStaleIndexLock() {
+	dialog.ShowConfirm("Remove index.lock?", "A git program crashed or was killed while working on this repository and left it locked.\nNo git is running right now, so it is safe to remove the lock. Close any other git client first if you have one open.", func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		defer project.refreshRepo()
+		err := core.RemoveStaleIndexLock(project.Repo())
+		if err != nil {
+			project.showError(err)
+		}
+	}, GetApp().Window)
+}
+
+func (project *ProjectController) pruneLFS() {
+	dialog.ShowConfirm("Free up LFS space?", "Removes the local copies of LFS files that are not used by what you have checked out and are safely on the server.\nThey are downloaded again if you ever need them.", func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		project.runCancellable("Freeing up space...", core.PruneLFS, project.showError)
+	}, GetApp().Window)
+}
+
+func (project *ProjectController) openSettings() {
+	project.Settings.UpdateData(project.Repo().Config)
+	project.Settings.Show()
+}
+
+func (project *ProjectController) saveSettings(config core.Config) {
+	_, err := config.ClassificationRules.Compile()
+	if err != nil {
+		project.showError(err)
+		return
+	}
+
+	err = core.SaveConfig(config)
+	if err != nil {
+		project.showError(err)
+		return
+	}
+
+	project.repo.Store(project.Repo().WithConfig(config))
+	project.Settings.Hide()
+	project.refreshProject()
+}
+
+func (project *ProjectController) manageLocks() {
+
+	// defer project.refreshProject()
+	// if core.GetGitStatus(project.Repo()) != core.GIT_STATUS_OK {
+	// 	project.showError(fmt.Errorf("Repo not ok. Can't commit"))
+	// 	return
+	// }
+	//dialog.ShowInformation("Not implemented", "Not implemented yet :P", GetApp().Window)
+	if locksErr := project.getLocksErr(); locksErr != nil {
+		project.showError(fmt.Errorf("Couldn't get the locks. %w", locksErr))
+		return
+	}
+	project.LockDialog.Show()
+}
+
+// A part of the project screen that loads on its own worker
+type refreshSection struct {
+	data       binding.Untyped
+	spinner    *view.Spinner
+	generation atomic.Int64
+}
+
+func makeRefreshSection[T any](spinner *view.Spinner, apply func(*T)) *refreshSection {
+	section := &refreshSection{data: binding.NewUntyped(), spinner: spinner}
+	section.data.AddListener(binding.NewDataListener(func() {
+		data, _ := section.data.Get()
+		if data == nil {
+			// nothing loaded yet
+			return
+		}
+		apply(data.(*T))
+	}))
+	return section
+}
+
+// load runs on a worker and must return a new pointer every time, the binding compares them
+func (section *refreshSection) refresh(load func() any) {
+	generation := section.generation.Add(1)
+	section.spinner.Start()
+	go func() {
+		data := load()
+		if section.generation.Load() != generation {
+			// a newer refresh started while we were loading, that one wins
+			return
+		}
+		section.data.Set(data)
+		section.spinner.Stop()
+	}()
+}
+
+func (project *ProjectController) refreshProject() {
+	// what we have locally shows up right away, a slow or stuck fetch doesn't hold it back
+	project.refreshRepo()
+	project.fetch()
+	// refresh build
+	// reresh other stuff?
+}
+
+// Fetches on a worker and refreshes what the fetch can change once it's done, so we know what a pull would bring in
+func (project *ProjectController) fetch() {
+	if !project.fetching.CompareAndSwap(false, true) {
+		// the running one refreshes when it's done
+		return
+	}
+	go func() {
+		defer project.fetching.Store(false)
+		err := core.GitFetch(project.Repo().WithContext(project.ctx))
+		project.applyFetchResult(err)
+		if err != nil {
+			return
+		}
+		project.refreshStatus()
+		project.refreshCommits()
+	}()
+}
+
+func (project *ProjectController) refreshRepo() {
+	project.refreshStatus()
+	project.refreshLocks()
+	project.refreshCommits()
+}
+
+func (project *ProjectController) refreshStatus() {
+	project.status.refresh(project.loadStatus)
+}
+
+func (project *ProjectController) refreshLocks() {
+	project.locks.refresh(project.loadLocks)
+}
+
+func (project *ProjectController) refreshCommits() {
+	branch := project.getSelectedBranch()
+	project.commits.refresh(func() any { return project.loadCommits(branch) })
+}
+
+// An offline or unauthorized fetch would otherwise look like a repo that is up to date
+func (project *ProjectController) applyFetchResult(err error) {
+	if err == nil {
+		project.ProjectStatus.FetchStatus.Hide()
+		project.ProjectStatus.FetchErrorLink.Hide()
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		// stopped on purpose, we know as much as before
+		return
+	}
+	project.ProjectStatus.FetchStatus.Show()
+	project.ProjectStatus.FetchErrorLink.Show()
+	project.ProjectStatus.FetchErrorLinkCallback = func() {
+		project.showError(fmt.Errorf("Couldn't fetch from %s, ahead and behind may be out of date. %w", project.Repo().Config.RemoteName, err))
+	}
+}
+
+func (project *ProjectController) loadStatus() any {
+	// one snapshot for the whole load, the settings can change meanwhile
+	repo := project.Repo()
+	data := &model.RepoStatusData{}
+	data.NeedsUsernameFix, data.UserErr = core.NeedsUsernameFix(repo)
+	if data.UserErr == nil {
+		data.Username, data.UserErr = core.GetUsernameFromRepo(repo)
+	}
+	if data.UserErr == nil {
+		data.Email, data.UserErr = core.GetUserEmailFromRepo(repo)
+	}
+	data.ConfigStatus, data.ConfigErr = core.GetGitConfigStatus(repo)
+
+	snapshot, err := core.GetRepoSnapshot(repo, true)
+	if err != nil {
+		data.Snapshot = &core.RepoSnapshot{}
+		data.Status = core.GIT_STATUS_UNKNOWN
+		data.StatusErr = err
+		return data
+	}
+	data.Snapshot = snapshot
+	data.Status, data.StatusErr = core.GetGitStatusFromSnapshot(repo, snapshot)
+	return data
+}
+
+func (project *ProjectController) loadLocks() any {
+	repo := project.Repo()
+	data := &model.LocksData{}
+	user, err := core.GetUsernameFromRepo(repo)
+	if err != nil {
+		data.Err = err
+		return data
+	}
+	data.Locked, data.Err = core.GetLockedFiles(repo, user)
+	if data.Err == nil && len(data.Locked) > 0 {
+		data.Unchanged, data.Err = core.ListLFSLockedUnchangedFiles(repo)
+	}
+	return data
+}
+
+func (project *ProjectController) loadCommits(branch string) any {
+	repo := project.Repo()
+	data := &model.CommitsData{Branch: branch}
+	data.Branches, data.Err = core.GetBranches(repo)
+	if data.Err != nil {
+		return data
+	}
+	if branch != "HEAD" && !slices.Contains(data.Branches, branch) {
+		// the branch is gone, deleted or pruned
+		data.Branch = "HEAD"
+	}
+	data.Commits, data.Err = core.GetRepoBranchInfo(repo, data.Branch, 0, core.COMMIT_PAGE_SIZE)
+	return data
+}
+
+func (project *ProjectController) applyStatus(data *model.RepoStatusData) {
+	project.applyRepoStatus(data)
+	project.applyRepoUserData(data)
+	project.applyRepoConfigStatus(data.ConfigStatus, data.ConfigErr)
+	project.applyRepoActions(data)
+}
+
+func (project *ProjectController) applyRepoUserData(data *model.RepoStatusData) {
+	if data.UserErr != nil {
+		project.ProjectStatus.RepoUser.SetText("Couldn't read your username")
+		project.ProjectStatus.RepoUser.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.RepoUser.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.FixUserLink.SetText("Change")
+	} else if data.NeedsUsernameFix {
+		project.ProjectStatus.RepoUser.SetText("Username missing!")
+		project.ProjectStatus.RepoUser.SetIcon(theme.ErrorIcon())
+		project.ProjectStatus.RepoUser.SetColor(theme.ColorNameError)
+		project.ProjectStatus.FixUserLink.SetText("Fix")
+	} else {
+		project.ProjectStatus.RepoUser.SetText(data.Username + " (" + data.Email + ")")
+		project.ProjectStatus.RepoUser.SetIcon(theme.AccountIcon())
+		project.ProjectStatus.RepoUser.SetColor(theme.ColorNameForeground)
+		project.ProjectStatus.FixUserLink.SetText("Change")
+	}
+	project.ProjectStatus.FixUserLinkCallback = func() {
+		provider, _ := core.GetGitProviderName(project.Repo())
+		ShowUsernameEmailDialog(provider,
+			func(username string, email string) error {
+				err := core.SetUsernameAndEmail(project.Repo(), username, email)
+				if err != nil {
+					return err
+				}
+				project.refreshRepo()
+				return nil
+			})
+	}
+}
+
+func (project *ProjectController) applyRepoConfigStatus(configStatus core.GitConfigStatus, configErr error) {
+	switch configStatus {
+	case core.CONFIG_STATUS_UNKNOWN:
+		project.ProjectStatus.ConfigStatus.SetText("Couldn't read the .gitconfig setup")
+		project.ProjectStatus.ConfigStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.ConfigStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixConfigLink.SetText("Why?")
+		project.ProjectStatus.FixConfigLink.Show()
+		project.ProjectStatus.FixConfigLinkCallback = func() {
+			project.showError(configErr)
+		}
+	case core.CONFIG_STATUS_MISSING:
+		project.ProjectStatus.ConfigStatus.SetText(".gitconfig missing")
+		project.ProjectStatus.ConfigStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.ConfigStatus.SetIcon(theme.QuestionIcon())
+		project.ProjectStatus.FixConfigLink.SetText("Create")
+		project.ProjectStatus.FixConfigLink.Show()
+		project.ProjectStatus.FixConfigLinkCallback = func() {
+			err := core.CreateGitConfig(project.Repo())
+			if err != nil {
+				project.showError(err)
+			}
+			project.refreshStatus()
+		}
+	case core.CONFIG_STATUS_NOT_LINKED:
+		project.ProjectStatus.ConfigStatus.SetText(".gitconfig found but not installed!")
+		project.ProjectStatus.ConfigStatus.SetColor(theme.ColorNameError)
+		project.ProjectStatus.ConfigStatus.SetIcon(theme.ErrorIcon())
+		project.ProjectStatus.FixConfigLink.SetText("Fix")
+		project.ProjectStatus.FixConfigLink.Show()
+		project.ProjectStatus.FixConfigLinkCallback = func() {
+			err := core.LinkGitConfig(project.Repo())
+			if err != nil {
+				project.showError(err)
+			}
+			project.refreshStatus()
+		}
+	case core.CONFIG_STATUS_LINKED:
+		project.ProjectStatus.ConfigStatus.SetText(".gitconfig linked")
+		project.ProjectStatus.ConfigStatus.SetColor(theme.ColorNameSuccess)
+		project.ProjectStatus.ConfigStatus.SetIcon(theme.ConfirmIcon())
+		project.ProjectStatus.FixConfigLink.Hide()
+	}
+}
+
+func (project *ProjectController) applyRepoStatus(data *model.RepoStatusData) {
+	switch data.Status {
+	case core.GIT_STATUS_REBASE_CONTINUABLE, core.GIT_STATUS_REBASE_CONFLICTS, core.GIT_STATUS_MERGE_IN_PROGRESS, core.GIT_STATUS_CHERRY_PICK_IN_PROGRESS, core.GIT_STATUS_BISECT_IN_PROGRESS:
+		project.ProjectStatus.AbortRepoStatusLink.Show()
+	default:
+		project.ProjectStatus.AbortRepoStatusLink.Hide()
+	}
+
+	switch data.Status {
+	case core.GIT_STATUS_UNKNOWN:
+		project.ProjectStatus.RepoStatus.SetText("Couldn't read the repo status")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.SetText("why?")
+		project.ProjectStatus.FixRepoStatusLink.Show()
+		project.ProjectStatus.FixRepoStatusCallback = func() {
+			project.showError(data.StatusErr)
+		}
+	case core.GIT_STATUS_OK:
+		project.ProjectStatus.RepoStatus.SetText("Repo ok")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameSuccess)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.ConfirmIcon())
+		project.ProjectStatus.FixRepoStatusLink.Hide()
+	case core.GIT_STATUS_SHALLOW:
+		project.ProjectStatus.RepoStatus.SetText("Repo is shallow!")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.SetText("unshallow")
+		project.ProjectStatus.FixRepoStatusLink.Show()
+		project.ProjectStatus.FixRepoStatusCallback = func() {
+			project.runCancellable("Unshallowing (This will take a while)...", core.UnshallowRepo, func(err error) {
+				project.refreshRepo()
+				project.showError(err)
+			})
+		}
+	case core.GIT_STATUS_REBASE_CONTINUABLE:
+		project.ProjectStatus.RepoStatus.SetText("Rebase underway, ready to continue")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameForeground)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.SetText("continue")
+		project.ProjectStatus.FixRepoStatusLink.Show()
+		project.ProjectStatus.FixRepoStatusCallback = func() {
+			project.runCancellable("Rebasing...", core.FinishRebase, func(err error) {
+				project.refreshRepo()
+				project.showError(err)
+			})
+		}
+	case core.GIT_STATUS_REBASE_CONFLICTS:
+		project.ProjectStatus.RepoStatus.SetText("Rebase underway, conflicts detected!")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameError)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.ErrorIcon())
+		project.ProjectStatus.FixRepoStatusLink.SetText("resolve")
+		project.ProjectStatus.FixRepoStatusLink.Show()
+		project.ProjectStatus.FixRepoStatusCallback = project.showConflicts
+	case core.GIT_STATUS_LAST_COMMIT_MERGE:
+		project.ProjectStatus.RepoStatus.SetText("Merge commit detected! This shouldn't have happened!")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.SetText("fix history")
+		project.ProjectStatus.FixRepoStatusLink.Show()
+		project.ProjectStatus.FixRepoStatusCallback = project.previewLinearize
+	case core.GIT_STATUS_MERGE_IN_PROGRESS:
+		project.ProjectStatus.RepoStatus.SetText("Merge underway! Finish it from a terminal or abort it")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.Hide()
+	case core.GIT_STATUS_CHERRY_PICK_IN_PROGRESS:
+		project.ProjectStatus.RepoStatus.SetText("Cherry-pick underway! Finish it from a terminal or abort it")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.Hide()
+	case core.GIT_STATUS_BISECT_IN_PROGRESS:
+		project.ProjectStatus.RepoStatus.SetText("Bisect underway! Finish it from a terminal or abort it")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.Hide()
+	case core.GIT_STATUS_STALE_INDEX_LOCK:
+		project.ProjectStatus.RepoStatus.SetText("Locked by a git that crashed (index.lock)")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameError)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.ErrorIcon())
+		project.ProjectStatus.FixRepoStatusLink.SetText("remove lock")
+		project.ProjectStatus.FixRepoStatusLink.Show()
+		project.ProjectStatus.FixRepoStatusCallback = project.removeStaleIndexLock
+	case core.GIT_STATUS_DEATACHED_HEAD:
+		project.ProjectStatus.RepoStatus.SetText("Currently in a Flashback (Deatached HEAD)")
+		project.ProjectStatus.RepoStatus.SetColor(theme.ColorNameWarning)
+		project.ProjectStatus.RepoStatus.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.FixRepoStatusLink.SetText("end Flashback")
+		project.ProjectStatus.FixRepoStatusCallback = func() {
+			project.runCancellable("Returning...", core.ReturnToLastBranch, func(err error) {
+				project.refreshRepo()
+				project.showError(err)
+			})
+		}
+		project.ProjectStatus.FixRepoStatusLink.Show()
+	}
+
+	snapshot := data.Snapshot
+	if data.Status == core.GIT_STATUS_UNKNOWN {
+		// zeros would look like everything is in sync
+		project.ProjectStatus.RepoBranch.SetText("unknown branch")
+		project.ProjectStatus.RepoBranch.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.RepoAhead.Hide()
+		project.ProjectStatus.RepoBehind.Hide()
+		project.ProjectStatus.RepoWorkingTree.Hide()
+	} else if snapshot.DeatachedHead {
+		project.ProjectStatus.RepoBranch.SetText("in a Flashback")
+		project.ProjectStatus.RepoBranch.SetIcon(theme.WarningIcon())
+		project.ProjectStatus.RepoAhead.Hide()
+		project.ProjectStatus.RepoBehind.Hide()
+		project.ProjectStatus.RepoWorkingTree.Hide()
+	} else {
+		project.ProjectStatus.RepoAhead.SetText(strconv.Itoa(snapshot.Ahead))
+		project.ProjectStatus.RepoBehind.SetText(strconv.Itoa(snapshot.Behind))
+		project.ProjectStatus.RepoAhead.Show()
+		project.ProjectStatus.RepoBehind.Show()
+
+		if snapshot.ChangeAmount() == 0 {
+			project.ProjectStatus.RepoWorkingTree.Hide()
+		} else {
+			project.ProjectStatus.RepoWorkingTree.Show()
+			project.ProjectStatus.RepoWorkingTree.SetText(strconv.Itoa(snapshot.ChangeAmount()))
+		}
+
+		project.ProjectStatus.RepoBranch.SetText(snapshot.Branch)
+		project.ProjectStatus.RepoBranch.SetIcon(assets.ResBranchSvg)
+	}
+}
+
+func (project *ProjectController) applyLocks(data *model.LocksData) {
+	if data.Err != nil {
+		// the server is unreachable or git-lfs is missing, don't pretend there are no locks
+		project.ProjectStatus.RepoLockedFiles.Show()
+		project.ProjectStatus.RepoLockedFiles.SetText("?")
+		project.ProjectStatus.RepoLockedFiles.SetColor(theme.ColorNameError)
+		project.LockDialog.UpdateData(nil, nil)
+		project.ProjectStatus.LockButton.Show()
+		project.setLocksErr(data.Err)
+		return
+	}
+	project.setLocksErr(nil)
+	project.ProjectStatus.RepoLockedFiles.SetColor(theme.ColorNameWarning)
+	if len(data.Locked) == 0 {
+		project.ProjectStatus.RepoLockedFiles.Hide()
+		project.LockDialog.UpdateData(data.Locked, data.Locked)
+		project.ProjectStatus.LockButton.Hide()
+	} else {
+		project.ProjectStatus.RepoLockedFiles.Show()
+		project.ProjectStatus.RepoLockedFiles.SetText(strconv.Itoa(len(data.Locked)))
+		project.LockDialog.UpdateData(data.Locked, data.Unchanged)
+		project.ProjectStatus.LockButton.Show()
+	}
+}
+
+func (project *ProjectController) applyRepoActions(data *model.RepoStatusData) {
+	if data.Status != core.GIT_STATUS_OK {
+		project.ProjectStatus.PullButton.Disable()
+		project.ProjectStatus.SyncButton.Disable()
+		project.ProjectStatus.CommitButton.Disable()
+
+		project.ProjectStatus.PullButton.SetText("Repo not ok. Can't pull")
+		project.ProjectStatus.SyncButton.SetText("Repo not ok. Can't sync")
+		project.ProjectStatus.CommitButton.SetText("Repo not ok. Can't commit")
+		return
+	}
+
+	snapshot := data.Snapshot
+	if snapshot.ChangeAmount() > 0 {
+		project.ProjectStatus.CommitButton.SetText("Commit")
+		project.ProjectStatus.CommitButton.Enable()
+	} else {
+		project.ProjectStatus.CommitButton.SetText("Nothing to commit")
+		project.ProjectStatus.CommitButton.Disable()
+	}
+
+	if snapshot.Behind > 0 {
+		project.ProjectStatus.PullButton.SetText("Pull")
+		project.ProjectStatus.PullButton.Enable()
+	} else {
+		project.ProjectStatus.PullButton.SetText("Nothing to pull")
+		project.ProjectStatus.PullButton.Disable()
+	}
+
+	if snapshot.Behind > 0 || snapshot.Ahead > 0 {
+		project.ProjectStatus.SyncButton.SetText("Sync")
+		project.ProjectStatus.SyncButton.Enable()
+	} else {
+		project.ProjectStatus.SyncButton.SetText("Nothing to sync")
+		project.ProjectStatus.SyncButton.Disable()
+	}
+
+}
+
+func (project *ProjectController) applyCommits(data *model.CommitsData) {
+	if data.Err != nil {
+		project.logError(fmt.Errorf("Couldn't load the history: %w", data.Err))
+		return
+	}
+	project.setSelectedBranch(data.Branch)
+	project.CommitList.SetBranches(append([]string{"HEAD"}, data.Branches...), data.Branch)
+	project.CommitList.UpdateCommits(data.Commits)
+	project.CommitList.SetHasMore(len(data.Commits) == core.COMMIT_PAGE_SIZE)
+}
+
+func (project *ProjectController) loadMoreCommits() {
+	generation := project.commits.generation.Load()
+	branch := project.getSelectedBranch()
+	offset := project.CommitList.CommitCount()
+	project.CommitList.Spinner.Start()
+	project.CommitList.LoadMoreButton.Disable()
+	go func() {
+		commits, err := core.GetRepoBranchInfo(project.Repo(), branch, offset, core.COMMIT_PAGE_SIZE)
+		if project.commits.generation.Load() != generation {
+			// the list was reloaded meanwhile, these don't belong to it anymore.
+			// The spinner is the reload's now, it stops it when it lands
+			project.CommitList.LoadMoreButton.Enable()
+			return
+		}
+		project.CommitList.Spinner.Stop()
+		project.CommitList.LoadMoreButton.Enable()
+		if err != nil {
+			project.showError(err)
+			return
+		}
+		project.CommitList.AppendCommits(commits)
+		project.CommitList.SetHasMore(len(commits) == core.COMMIT_PAGE_SIZE)
+	}()
+}
+
+func (project *ProjectController) branchSelected(branch string) {
+	if branch == project.getSelectedBranch() {
+		return
+	}
+	project.setSelectedBranch(branch)
+	project.refreshCommits()
+}
+
+func (project *ProjectController) Repo() *core.Repo {
+	return project.repo.Load()
+}
+
+func (project *ProjectController) getSelectedBranch() string {
+	project.stateMutex.Lock()
+	defer project.stateMutex.Unlock()
+	return project.selectedBranch
+}
+
+func (project *ProjectController) setSelectedBranch(branch string) {
+	project.stateMutex.Lock()
+	defer project.stateMutex.Unlock()
+	project.selectedBranch = branch
+}
+
+func (project *ProjectController) getLocksErr() error {
+	project.stateMutex.Lock()
+	defer project.stateMutex.Unlock()
+	return project.locksErr
+}
+
+func (project *ProjectController) setLocksErr(err error) {
+	project.stateMutex.Lock()
+	defer project.stateMutex.Unlock()
+	project.locksErr = err
+}
+
+func openFilePickerUproject() {
+	file, err := zenity.SelectFile(
+		zenity.Filename("./"),
+		zenity.Title("Select Unreal Engine Project file"),
+		zenity.Modal(),
+		zenity.FileFilters{
+			{
+				Name:     "Unreal Engine Project files",
+				Patterns: []string{"*.uproject"},
+				CaseFold: false,
+			},
+		},
+	)
+	if err == nil {
+		UProjectOpened(file)
+	}
+}
+
+func ForgetProject(projectFile string) {
+	config := GetConfig()
+	foundIdx := slices.Index(config.RecentProjects, projectFile)
+	if foundIdx != -1 {
+		// Remove it from the list
+		config.RecentProjects = slices.Delete(config.RecentProjects, foundIdx, foundIdx+1)
+	}
+	SaveConfig()
+}
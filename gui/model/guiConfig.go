@@ -1,5 +1,5 @@
-package model
-
-type GUIConfig struct {
-	RecentProjects []string
-}
+package model
+
+type GUIConfig struct {
+	RecentProjects []string
+}
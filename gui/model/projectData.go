@@ -0,0 +1,31 @@
+package model
+
+import "github.com/miltoncandelero/ugsg/core"
+
+// Snapshots of the repo loaded on a worker and pushed to the views through bindings
+
+// Each part has its own error so one failing doesn't hide the others, the views show it as unknown
+type RepoStatusData struct {
+	Snapshot         *core.RepoSnapshot
+	Status           core.GitStatus
+	StatusErr        error
+	NeedsUsernameFix bool
+	Username         string
+	Email            string
+	UserErr          error
+	ConfigStatus     core.GitConfigStatus
+	ConfigErr        error
+}
+
+type LocksData struct {
+	Locked    []core.LockDatum
+	Unchanged []core.LockDatum
+	Err       error
+}
+
+type CommitsData struct {
+	Branches []string
+	Branch   string
+	Commits  []*core.CommitDatum
+	Err      error
+}
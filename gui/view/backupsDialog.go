@@ -0,0 +1,106 @@
+package view
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+// Lists the backups taken before time travels, pulls and such, to undo them
+type BackupsDialog struct {
+	*dialog.CustomDialog
+	fyneWidget *widget.List
+	restoreBtn *widget.Button
+
+	Backups  []core.BackupDatum
+	Selected int
+
+	RestoreCallback func(core.BackupDatum)
+}
+
+var backupOperationNames = map[string]string{
+	core.BACKUP_OPERATION_RESET:     "Time travel",
+	core.BACKUP_OPERATION_CHECKOUT:  "Flashback",
+	core.BACKUP_OPERATION_PULL:      "Pull",
+	core.BACKUP_OPERATION_LINEARIZE: "Fix history",
+	core.BACKUP_OPERATION_RESTORE:   "Undo",
+}
+
+func (this *BackupsDialog) UpdateData(backups []core.BackupDatum) {
+	this.Backups = backups
+	this.Selected = -1
+	this.fyneWidget.UnselectAll()
+	this.restoreBtn.Disable()
+	this.fyneWidget.Refresh()
+}
+
+func MakeBackupsDialog(window fyne.Window) *BackupsDialog {
+	retval := &BackupsDialog{Selected: -1}
+	retval.Backups = make([]core.BackupDatum, 0)
+
+	retval.fyneWidget = widget.NewList(
+		func() int {
+			return len(retval.Backups)
+		},
+		func() fyne.CanvasObject {
+			msgLabel := widget.NewLabel("")
+			msgLabel.Truncation = fyne.TextTruncateEllipsis
+			branchLabel := widget.NewLabel("")
+			branchLabel.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, widget.NewLabel(""), branchLabel, msgLabel)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			backup := retval.Backups[id]
+			operation, ok := backupOperationNames[backup.Operation]
+			if !ok {
+				operation = backup.Operation
+			}
+			branch := backup.Branch
+			if branch == "HEAD" {
+				branch = "in a Flashback"
+			}
+			// border puts the center object first
+			objects := o.(*fyne.Container).Objects
+			objects[0].(*widget.Label).SetText("back to \"" + backup.Msg + "\"")
+			objects[1].(*widget.Label).SetText(backup.Date.Format("2006-01-02 15:04:05") + "  Before " + operation)
+			objects[2].(*widget.Label).SetText(branch)
+		})
+	retval.fyneWidget.OnSelected = func(id widget.ListItemID) {
+		retval.Selected = id
+		retval.restoreBtn.Enable()
+	}
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(800, 400))
+	listContainer := container.NewStack(rect, retval.fyneWidget)
+
+	explanation := widget.NewLabel("Every time travel, flashback or pull that rewrites history saves where you were first. Pick one to go back to it.")
+	explanation.Wrapping = fyne.TextWrapWord
+
+	closeBtn := widget.NewButton("Close", nil)
+	retval.restoreBtn = widget.NewButton("Go back", func() {
+		if retval.Selected < 0 || retval.Selected >= len(retval.Backups) {
+			return
+		}
+		retval.RestoreCallback(retval.Backups[retval.Selected])
+	})
+	retval.restoreBtn.Importance = widget.HighImportance
+	retval.restoreBtn.Disable()
+	bottomContainer := container.NewBorder(nil, nil, nil, container.NewHBox(closeBtn, retval.restoreBtn))
+
+	border := container.NewBorder(explanation, bottomContainer, nil, nil, listContainer)
+
+	dialog := dialog.NewCustomWithoutButtons("Undo", border, window)
+	closeBtn.OnTapped = func() {
+		dialog.Hide()
+	}
+
+	retval.CustomDialog = dialog
+
+	return retval
+}
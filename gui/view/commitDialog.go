@@ -0,0 +1,193 @@
+package view
+
+import (
+	"image/color"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+type CommitFileItem struct {
+	// extends widget
+	widget.BaseWidget
+
+	Container *fyne.Container
+
+	File string
+
+	parent *CommitFilesList
+
+	Checkbox  *widget.Check
+	FileLabel *widget.Label
+	LockLabel *widget.Label
+}
+
+func (this *CommitFileItem) CreateRenderer() fyne.WidgetRenderer {
+	this.ExtendBaseWidget(this)
+	return widget.NewSimpleRenderer(this.Container)
+}
+
+func (this *CommitFileItem) Tapped(_ *fyne.PointEvent) {
+	if this.Checkbox.Disabled() {
+		return
+	}
+	this.Checkbox.SetChecked(!this.Checkbox.Checked)
+}
+
+func (this *CommitFileItem) Recycle(file string) {
+	this.File = file
+	this.FileLabel.SetText(file)
+	if slices.Contains(this.parent.MissingLock, file) {
+		this.Checkbox.SetChecked(false)
+		this.Checkbox.Disable()
+		this.LockLabel.SetText("Not locked by you!")
+		this.LockLabel.Show()
+	} else {
+		this.Checkbox.Enable()
+		this.Checkbox.SetChecked(this.parent.Selected[file])
+		this.LockLabel.Hide()
+	}
+	this.Refresh()
+}
+
+func MakeCommitFileItem(listRef *CommitFilesList) *CommitFileItem {
+	retval := &CommitFileItem{}
+	retval.parent = listRef
+	retval.Checkbox = widget.NewCheck("", func(b bool) {
+		if b {
+			retval.parent.Selected[retval.File] = true
+		} else {
+			delete(retval.parent.Selected, retval.File)
+		}
+	})
+	retval.FileLabel = widget.NewLabel("")
+	retval.FileLabel.Truncation = fyne.TextTruncateEllipsis
+	retval.LockLabel = widget.NewLabel("")
+	retval.LockLabel.Importance = widget.DangerImportance
+	retval.Container = container.NewBorder(nil, nil, retval.Checkbox, retval.LockLabel, retval.FileLabel)
+	retval.ExtendBaseWidget(retval)
+	retval.Refresh()
+	return retval
+}
+
+type CommitFilesList struct {
+	fyneWidget *widget.List
+
+	Container *fyne.Container
+
+	Files       []string
+	MissingLock []string
+	Selected    map[string]bool
+}
+
+func MakeCommitFilesList() *CommitFilesList {
+	retval := &CommitFilesList{}
+
+	retval.Files = make([]string, 0)
+	retval.MissingLock = make([]string, 0)
+	retval.Selected = make(map[string]bool, 0)
+
+	retval.fyneWidget = widget.NewList(
+		func() int {
+			return len(retval.Files)
+		},
+		func() fyne.CanvasObject {
+			return MakeCommitFileItem(retval)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			c := o.(*CommitFileItem)
+			c.Recycle(retval.Files[id])
+		})
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(800, 400))
+	retval.Container = container.NewStack(rect, retval.fyneWidget)
+
+	return retval
+}
+
+type CommitDialog struct {
+	*dialog.CustomDialog
+	filesList      *CommitFilesList
+	messageEntry   *widget.Entry
+	CommitCallback func([]string, string)
+}
+
+func (this *CommitDialog) UpdateData(files []string, missingLock []string) {
+	this.filesList.Files = files
+	this.filesList.MissingLock = missingLock
+	this.filesList.Selected = make(map[string]bool, len(files))
+	this.filesList.fyneWidget.Refresh()
+}
+
+func (this *CommitDialog) ClearMessage() {
+	this.messageEntry.SetText("")
+}
+
+func (this *CommitDialog) GetSelected() []string {
+	retval := make([]string, 0, len(this.filesList.Selected))
+	// keep the order of the list
+	for _, file := range this.filesList.Files {
+		if this.filesList.Selected[file] {
+			retval = append(retval, file)
+		}
+	}
+	return retval
+}
+
+func (this *CommitDialog) SelectAll() {
+	for _, file := range this.filesList.Files {
+		if !slices.Contains(this.filesList.MissingLock, file) {
+			this.filesList.Selected[file] = true
+		}
+	}
+	this.filesList.fyneWidget.Refresh()
+}
+
+func (this *CommitDialog) SelectNone() {
+	for _, file := range this.filesList.Files {
+		delete(this.filesList.Selected, file)
+	}
+	this.filesList.fyneWidget.Refresh()
+}
+
+func MakeCommitDialog(window fyne.Window) *CommitDialog {
+
+	retval := &CommitDialog{}
+
+	messageEntry := widget.NewMultiLineEntry()
+	messageEntry.SetPlaceHolder("Describe your changes")
+	messageEntry.Wrapping = fyne.TextWrapWord
+	messageEntry.SetMinRowsVisible(3)
+
+	closeBtn := widget.NewButton("Close", nil)
+	commitSelected := widget.NewButton("Commit selected", func() {
+		retval.CommitCallback(retval.GetSelected(), retval.messageEntry.Text)
+	})
+	commitSelected.Importance = widget.HighImportance
+	actionsContainer := container.NewVBox(widget.NewLabel("Commit message:"), messageEntry, commitSelected)
+	bottomContainer := container.NewBorder(actionsContainer, nil, nil, closeBtn, nil)
+
+	selectAllBtn := widget.NewButton("Select all", retval.SelectAll)
+	selectNoneBtn := widget.NewButton("Select none", retval.SelectNone)
+	selectAllNoneContainer := container.NewHBox(selectAllBtn, selectNoneBtn)
+	topContainer := container.NewBorder(nil, nil, selectAllNoneContainer, nil, nil)
+
+	filesList := MakeCommitFilesList()
+	border := container.NewBorder(topContainer, bottomContainer, nil, nil, filesList.Container)
+
+	dialog := dialog.NewCustomWithoutButtons("Commit", border, window)
+	closeBtn.OnTapped = func() {
+		dialog.Hide()
+	}
+
+	retval.CustomDialog = dialog
+	retval.filesList = filesList
+	retval.messageEntry = messageEntry
+
+	return retval
+}
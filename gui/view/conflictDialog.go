@@ -0,0 +1,169 @@
+package view
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+const (
+	KEEP_MINE_OPTION   = "Keep mine"
+	KEEP_THEIRS_OPTION = "Keep theirs"
+	KEEP_MERGED_OPTION = "I fixed it myself"
+)
+
+var resolutionOptions = map[string]core.ConflictResolution{
+	KEEP_MINE_OPTION:   core.CONFLICT_KEEP_MINE,
+	KEEP_THEIRS_OPTION: core.CONFLICT_KEEP_THEIRS,
+	KEEP_MERGED_OPTION: core.CONFLICT_KEEP_MERGED,
+}
+
+type ConflictItem struct {
+	// extends widget
+	widget.BaseWidget
+
+	Container *fyne.Container
+
+	Conflict core.ConflictDatum
+
+	parent *ConflictDialog
+
+	FileLabel    *widget.Label
+	AuthorsLabel *widget.Label
+	Resolution   *widget.RadioGroup
+}
+
+func (this *ConflictItem) CreateRenderer() fyne.WidgetRenderer {
+	this.ExtendBaseWidget(this)
+	return widget.NewSimpleRenderer(this.Container)
+}
+
+func describeSide(author string, deleted bool) string {
+	if author == "" {
+		author = "unknown"
+	}
+	if deleted {
+		return author + " (deleted it)"
+	}
+	return author
+}
+
+func (this *ConflictItem) Recycle(conflict core.ConflictDatum) {
+	this.Conflict = conflict
+	this.FileLabel.SetText(conflict.Path)
+	this.AuthorsLabel.SetText("Mine: " + describeSide(conflict.MineAuthor, conflict.MineDeleted) + "  -  Theirs: " + describeSide(conflict.TheirsAuthor, conflict.TheirsDeleted))
+	if conflict.Binary {
+		this.Resolution.Options = []string{KEEP_MINE_OPTION, KEEP_THEIRS_OPTION}
+	} else {
+		this.Resolution.Options = []string{KEEP_MINE_OPTION, KEEP_THEIRS_OPTION, KEEP_MERGED_OPTION}
+	}
+	selected := ""
+	for option, resolution := range resolutionOptions {
+		if this.parent.Resolutions[conflict.Path] == resolution {
+			selected = option
+		}
+	}
+	this.Resolution.SetSelected(selected)
+	this.Refresh()
+}
+
+func MakeConflictItem(dialogRef *ConflictDialog) *ConflictItem {
+	retval := &ConflictItem{}
+	retval.parent = dialogRef
+	retval.FileLabel = widget.NewLabel("")
+	retval.FileLabel.Truncation = fyne.TextTruncateEllipsis
+	retval.FileLabel.TextStyle = fyne.TextStyle{Bold: true}
+	retval.AuthorsLabel = widget.NewLabel("")
+	retval.AuthorsLabel.Importance = widget.LowImportance
+	retval.Resolution = widget.NewRadioGroup([]string{}, func(option string) {
+		if option == "" {
+			delete(retval.parent.Resolutions, retval.Conflict.Path)
+		} else {
+			retval.parent.Resolutions[retval.Conflict.Path] = resolutionOptions[option]
+		}
+	})
+	retval.Resolution.Horizontal = true
+	retval.Container = container.NewBorder(nil, nil, nil, retval.Resolution, container.NewVBox(retval.FileLabel, retval.AuthorsLabel))
+	retval.ExtendBaseWidget(retval)
+	retval.Refresh()
+	return retval
+}
+
+type ConflictDialog struct {
+	*dialog.CustomDialog
+	fyneWidget *widget.List
+
+	Conflicts   []core.ConflictDatum
+	Resolutions map[string]core.ConflictResolution
+
+	// Resolves the chosen files and continues the rebase if nothing is left
+	ResolveCallback func([]core.ConflictDatum, map[string]core.ConflictResolution)
+	AbortCallback   func()
+}
+
+func (this *ConflictDialog) UpdateData(conflicts []core.ConflictDatum) {
+	this.Conflicts = conflicts
+	this.Resolutions = make(map[string]core.ConflictResolution, len(conflicts))
+	this.fyneWidget.Refresh()
+}
+
+// Binary assets can only keep one side, so this works for every file
+func (this *ConflictDialog) KeepAll(resolution core.ConflictResolution) {
+	for _, conflict := range this.Conflicts {
+		this.Resolutions[conflict.Path] = resolution
+	}
+	this.fyneWidget.Refresh()
+}
+
+func MakeConflictDialog(window fyne.Window) *ConflictDialog {
+	retval := &ConflictDialog{}
+	retval.Conflicts = make([]core.ConflictDatum, 0)
+	retval.Resolutions = make(map[string]core.ConflictResolution, 0)
+
+	retval.fyneWidget = widget.NewList(
+		func() int {
+			return len(retval.Conflicts)
+		},
+		func() fyne.CanvasObject {
+			return MakeConflictItem(retval)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			c := o.(*ConflictItem)
+			c.Recycle(retval.Conflicts[id])
+		})
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(800, 400))
+	listContainer := container.NewStack(rect, retval.fyneWidget)
+
+	explanation := widget.NewLabel("Someone else changed the same files you did. Pick whose version survives for each file.\n\"Mine\" is your commit, \"theirs\" is what came from the server.")
+	explanation.Wrapping = fyne.TextWrapWord
+	keepAllMineBtn := widget.NewButton("All mine", func() { retval.KeepAll(core.CONFLICT_KEEP_MINE) })
+	keepAllTheirsBtn := widget.NewButton("All theirs", func() { retval.KeepAll(core.CONFLICT_KEEP_THEIRS) })
+	topContainer := container.NewVBox(explanation, container.NewHBox(keepAllMineBtn, keepAllTheirsBtn))
+
+	closeBtn := widget.NewButton("Close", nil)
+	abortBtn := widget.NewButton("Abort rebase", func() { retval.AbortCallback() })
+	abortBtn.Importance = widget.DangerImportance
+	resolveBtn := widget.NewButton("Resolve and continue", func() {
+		retval.ResolveCallback(retval.Conflicts, retval.Resolutions)
+	})
+	resolveBtn.Importance = widget.HighImportance
+	bottomContainer := container.NewBorder(nil, nil, abortBtn, container.NewHBox(closeBtn, resolveBtn))
+
+	border := container.NewBorder(topContainer, bottomContainer, nil, nil, listContainer)
+
+	dialog := dialog.NewCustomWithoutButtons("Resolve conflicts", border, window)
+	closeBtn.OnTapped = func() {
+		dialog.Hide()
+	}
+
+	retval.CustomDialog = dialog
+
+	return retval
+}
@@ -0,0 +1,164 @@
+package view
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+// Oldest lines are dropped past this
+const MAX_CONSOLE_LINES = 5000
+
+const (
+	CONSOLE_SHOW_EVERYTHING = "Everything"
+	CONSOLE_SHOW_COMMANDS   = "Commands"
+	CONSOLE_SHOW_ERRORS     = "Errors"
+)
+
+type ConsoleLine struct {
+	Kind core.CommandEventKind
+	Text string
+	// exit code for COMMAND_EXITED lines
+	Failed bool
+}
+
+// Collapsible panel with every command run on the repo and what it said
+type Console struct {
+	Container  fyne.CanvasObject
+	fyneWidget *widget.List
+
+	Lines []ConsoleLine
+	// indexes into Lines that pass the filter
+	shown []int
+
+	filterEntry *widget.Entry
+	showSelect  *widget.Select
+}
+
+func formatCommandEvent(event core.CommandEvent) ConsoleLine {
+	timestamp := event.Time.Format("15:04:05")
+	switch event.Kind {
+	case core.COMMAND_STARTED:
+		return ConsoleLine{Kind: event.Kind, Text: fmt.Sprintf("%s $ %s", timestamp, event.CommandLine())}
+	case core.COMMAND_EXITED:
+		return ConsoleLine{
+			Kind:   event.Kind,
+			Text:   fmt.Sprintf("%s exit %d in %s (%s)", timestamp, event.ExitCode, event.Duration.Round(time.Millisecond), event.Cmd),
+			Failed: event.ExitCode != 0,
+		}
+	}
+	// -z output would otherwise show up as nothing
+	return ConsoleLine{Kind: event.Kind, Text: "    " + strings.ReplaceAll(event.Line, "\x00", "␀")}
+}
+
+func (this *Console) AddEvents(events []core.CommandEvent) {
+	for _, event := range events {
+		this.Lines = append(this.Lines, formatCommandEvent(event))
+	}
+	if len(this.Lines) > MAX_CONSOLE_LINES {
+		this.Lines = this.Lines[len(this.Lines)-MAX_CONSOLE_LINES:]
+	}
+	this.applyFilter()
+	this.fyneWidget.ScrollToBottom()
+}
+
+func (this *Console) Clear() {
+	this.Lines = nil
+	this.applyFilter()
+}
+
+func (this *Console) matches(line ConsoleLine) bool {
+	switch this.showSelect.Selected {
+	case CONSOLE_SHOW_COMMANDS:
+		if line.Kind != core.COMMAND_STARTED && line.Kind != core.COMMAND_EXITED {
+			return false
+		}
+	case CONSOLE_SHOW_ERRORS:
+		if line.Kind != core.COMMAND_STDERR && !line.Failed {
+			return false
+		}
+	}
+	filter := strings.ToLower(strings.TrimSpace(this.filterEntry.Text))
+	return filter == "" || strings.Contains(strings.ToLower(line.Text), filter)
+}
+
+func (this *Console) applyFilter() {
+	this.shown = this.shown[:0]
+	for idx, line := range this.Lines {
+		if this.matches(line) {
+			this.shown = append(this.shown, idx)
+		}
+	}
+	this.fyneWidget.Refresh()
+}
+
+// What the console shows right now, filter included
+func (this *Console) GetText() string {
+	lines := make([]string, 0, len(this.shown))
+	for _, idx := range this.shown {
+		lines = append(lines, this.Lines[idx].Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func MakeConsole(window fyne.Window) *Console {
+	retval := &Console{}
+	retval.Lines = make([]ConsoleLine, 0)
+	retval.shown = make([]int, 0)
+
+	retval.fyneWidget = widget.NewList(
+		func() int {
+			return len(retval.shown)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			line := retval.Lines[retval.shown[id]]
+			label := o.(*widget.Label)
+			switch {
+			case line.Kind == core.COMMAND_STARTED:
+				label.Importance = widget.HighImportance
+			case line.Kind == core.COMMAND_STDERR, line.Failed:
+				label.Importance = widget.WarningImportance
+			case line.Kind == core.COMMAND_EXITED:
+				label.Importance = widget.LowImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.SetText(line.Text)
+		})
+
+	retval.filterEntry = widget.NewEntry()
+	retval.filterEntry.SetPlaceHolder("Filter")
+	retval.filterEntry.OnChanged = func(string) { retval.applyFilter() }
+
+	retval.showSelect = widget.NewSelect([]string{CONSOLE_SHOW_EVERYTHING, CONSOLE_SHOW_COMMANDS, CONSOLE_SHOW_ERRORS}, func(string) { retval.applyFilter() })
+	retval.showSelect.SetSelected(CONSOLE_SHOW_EVERYTHING)
+
+	copyBtn := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		window.Clipboard().SetContent(retval.GetText())
+	})
+	clearBtn := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), retval.Clear)
+
+	toolbar := container.NewBorder(nil, nil, nil, container.NewHBox(retval.showSelect, copyBtn, clearBtn), retval.filterEntry)
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(0, 200))
+	content := container.NewBorder(toolbar, nil, nil, nil, container.NewStack(rect, retval.fyneWidget))
+
+	retval.Container = widget.NewAccordion(widget.NewAccordionItem("Console", content))
+
+	return retval
+}
@@ -0,0 +1,75 @@
+package view
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+// Shows the commits a linearize would leave on top of the remote before doing it
+type LinearizeDialog struct {
+	*dialog.CustomDialog
+	fyneWidget *widget.List
+
+	Commits []*core.CommitDatum
+
+	LinearizeCallback func()
+}
+
+func (this *LinearizeDialog) UpdateData(commits []*core.CommitDatum) {
+	this.Commits = commits
+	this.fyneWidget.Refresh()
+}
+
+func MakeLinearizeDialog(window fyne.Window) *LinearizeDialog {
+	retval := &LinearizeDialog{}
+	retval.Commits = make([]*core.CommitDatum, 0)
+
+	retval.fyneWidget = widget.NewList(
+		func() int {
+			return len(retval.Commits)
+		},
+		func() fyne.CanvasObject {
+			msgLabel := widget.NewLabel("")
+			msgLabel.Truncation = fyne.TextTruncateEllipsis
+			userLabel := widget.NewLabel("")
+			userLabel.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, widget.NewLabel(""), userLabel, msgLabel)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			commit := retval.Commits[id]
+			// border puts the center object first
+			objects := o.(*fyne.Container).Objects
+			objects[0].(*widget.Label).SetText(commit.Msg)
+			objects[1].(*widget.Label).SetText(commit.Hash[:8])
+			objects[2].(*widget.Label).SetText(commit.User)
+		})
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(700, 300))
+	listContainer := container.NewStack(rect, retval.fyneWidget)
+
+	explanation := widget.NewLabel("Your unpushed commits include a merge. Fixing it puts these commits, oldest first, on top of the ones on the server and drops the merge.\nUncommitted changes are kept.")
+	explanation.Wrapping = fyne.TextWrapWord
+
+	closeBtn := widget.NewButton("Close", nil)
+	linearizeBtn := widget.NewButton("Fix history", func() { retval.LinearizeCallback() })
+	linearizeBtn.Importance = widget.HighImportance
+	bottomContainer := container.NewBorder(nil, nil, nil, container.NewHBox(closeBtn, linearizeBtn))
+
+	border := container.NewBorder(explanation, bottomContainer, nil, nil, listContainer)
+
+	dialog := dialog.NewCustomWithoutButtons("Fix merge commit", border, window)
+	closeBtn.OnTapped = func() {
+		dialog.Hide()
+	}
+
+	retval.CustomDialog = dialog
+
+	return retval
+}
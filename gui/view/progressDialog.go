@@ -0,0 +1,86 @@
+package view
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+// Loading dialog that turns into a real progress bar once git starts reporting how far along it is
+type ProgressDialog struct {
+	*dialog.CustomDialog
+
+	phaseLabel  *widget.Label
+	bar         *widget.ProgressBar
+	infiniteBar *widget.ProgressBarInfinite
+	detailLabel *widget.Label
+	cancelBtn   *widget.Button
+}
+
+func (this *ProgressDialog) UpdateProgress(progress core.Progress) {
+	this.phaseLabel.SetText(progress.Phase)
+
+	if progress.Total == 0 {
+		// git only knows how many it has gone through, not how many there are
+		this.bar.Hide()
+		this.infiniteBar.Show()
+		this.infiniteBar.Start()
+		this.detailLabel.SetText(fmt.Sprintf("%d", progress.Current))
+		return
+	}
+
+	this.infiniteBar.Stop()
+	this.infiniteBar.Hide()
+	this.bar.Show()
+	this.bar.SetValue(float64(progress.Percent) / 100)
+
+	detail := fmt.Sprintf("%d of %d", progress.Current, progress.Total)
+	if progress.Transferred != "" {
+		detail += ", " + progress.Transferred
+	}
+	if progress.Rate != "" && !progress.Done {
+		detail += " at " + progress.Rate
+	}
+	this.detailLabel.SetText(detail)
+}
+
+func (this *ProgressDialog) Hide() {
+	this.infiniteBar.Stop()
+	this.CustomDialog.Hide()
+}
+
+// cancel can be nil for work that can't be stopped halfway
+func MakeProgressDialog(title string, cancel func(), window fyne.Window) *ProgressDialog {
+	retval := &ProgressDialog{}
+
+	retval.phaseLabel = widget.NewLabel("")
+	retval.phaseLabel.Truncation = fyne.TextTruncateEllipsis
+	retval.bar = widget.NewProgressBar()
+	retval.bar.Hide()
+	retval.infiniteBar = widget.NewProgressBarInfinite()
+	retval.detailLabel = widget.NewLabel("")
+	retval.detailLabel.Importance = widget.LowImportance
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(300, 0))
+	content := container.NewVBox(retval.phaseLabel, container.NewStack(rect, retval.bar, retval.infiniteBar), retval.detailLabel)
+
+	if cancel != nil {
+		retval.cancelBtn = widget.NewButton("Cancel", func() {
+			retval.cancelBtn.SetText("Cancelling...")
+			retval.cancelBtn.Disable()
+			cancel()
+		})
+		content.Add(container.NewCenter(retval.cancelBtn))
+	}
+
+	retval.CustomDialog = dialog.NewCustomWithoutButtons(title, content, window)
+
+	return retval
+}
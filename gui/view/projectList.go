@@ -1,21 +1,21 @@
-package view
-
-import (
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/widget"
-)
-
-func MakeCardListsFromProjects(projects *[]string, callback func(string), forget func(string)) fyne.CanvasObject {
-	var retval *widget.List
-	retval = widget.NewList(
-		func() int {
-			return len(*projects)
-		},
-		func() fyne.CanvasObject {
-			return MakeCardFromProject("")
-		},
-		func(id widget.ListItemID, item fyne.CanvasObject) {
-			item.(*ProjectCard).SetProject((*projects)[id], callback, retval.Refresh, forget)
-		})
-	return retval
-}
+package view
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+func MakeCardListsFromProjects(projects *[]string, callback func(string), forget func(string)) fyne.CanvasObject {
+	var retval *widget.List
+	retval = widget.NewList(
+		func() int {
+			return len(*projects)
+		},
+		func() fyne.CanvasObject {
+			return MakeCardFromProject("")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*ProjectCard).SetProject((*projects)[id], callback, retval.Refresh, forget)
+		})
+	return retval
+}
@@ -0,0 +1,178 @@
+package view
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+type SettingsDialog struct {
+	*dialog.CustomDialog
+
+	config core.Config
+
+	gitPathEntry         *widget.Entry
+	remoteNameEntry      *widget.Entry
+	mainBranchEntry      *widget.Entry
+	rulesEntry           *widget.Entry
+	requireLocksCheck    *widget.Check
+	unlockAfterPushCheck *widget.Check
+	timeoutEntry         *widget.Entry
+	longTimeoutEntry     *widget.Entry
+
+	SaveCallback func(core.Config)
+}
+
+// One rule per line, "Category: pattern"
+func RulesToText(rules core.ClassificationRules) string {
+	lines := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, string(rule.Category)+": "+rule.Pattern)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TextToRules(text string) (core.ClassificationRules, error) {
+	rules := make(core.ClassificationRules, 0)
+	for idx, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		category, pattern, found := strings.Cut(line, ":")
+		category = strings.TrimSpace(category)
+		pattern = strings.TrimSpace(pattern)
+		if !found || category == "" || pattern == "" {
+			return nil, fmt.Errorf("Rule on line %d should look like \"Category: pattern\"", idx+1)
+		}
+		rules = append(rules, core.ClassificationRule{Category: core.ChangeCategory(category), Pattern: pattern})
+	}
+	return rules, nil
+}
+
+func (this *SettingsDialog) UpdateData(config core.Config) {
+	this.config = config
+	this.gitPathEntry.SetText(config.GitExecPath)
+	this.remoteNameEntry.SetText(config.RemoteName)
+	this.mainBranchEntry.SetText(config.MainBranch)
+	this.rulesEntry.SetText(RulesToText(config.ClassificationRules))
+	this.requireLocksCheck.SetChecked(config.RequireLocksToCommit)
+	this.unlockAfterPushCheck.SetChecked(config.UnlockAfterPush)
+	this.timeoutEntry.SetText(strconv.Itoa(config.CommandTimeout))
+	this.longTimeoutEntry.SetText(strconv.Itoa(config.LongCommandTimeout))
+}
+
+func parseTimeout(name string, text string) (int, error) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("%s should be a number of seconds (0 for no limit)", name)
+	}
+	return seconds, nil
+}
+
+// Every git command would fail with something unclear if these were empty
+func parseRequired(name string, text string) (string, error) {
+	value := strings.TrimSpace(text)
+	if value == "" {
+		return "", fmt.Errorf("%s can't be empty", name)
+	}
+	return value, nil
+}
+
+func (this *SettingsDialog) GetConfig() (core.Config, error) {
+	// starts from the loaded one so fields not on screen survive
+	config := this.config
+	var err error
+	config.GitExecPath, err = parseRequired("Git executable", this.gitPathEntry.Text)
+	if err != nil {
+		return config, err
+	}
+	config.RemoteName, err = parseRequired("Remote name", this.remoteNameEntry.Text)
+	if err != nil {
+		return config, err
+	}
+	config.MainBranch, err = parseRequired("Main branch", this.mainBranchEntry.Text)
+	if err != nil {
+		return config, err
+	}
+	config.RequireLocksToCommit = this.requireLocksCheck.Checked
+	config.UnlockAfterPush = this.unlockAfterPushCheck.Checked
+
+	timeout, err := parseTimeout("Command timeout", this.timeoutEntry.Text)
+	if err != nil {
+		return config, err
+	}
+	config.CommandTimeout = timeout
+	longTimeout, err := parseTimeout("Long command timeout", this.longTimeoutEntry.Text)
+	if err != nil {
+		return config, err
+	}
+	config.LongCommandTimeout = longTimeout
+
+	rules, err := TextToRules(this.rulesEntry.Text)
+	if err != nil {
+		return config, err
+	}
+	config.ClassificationRules = rules
+
+	return config, nil
+}
+
+func MakeSettingsDialog(window fyne.Window) *SettingsDialog {
+	retval := &SettingsDialog{}
+
+	retval.gitPathEntry = widget.NewEntry()
+	retval.remoteNameEntry = widget.NewEntry()
+	retval.mainBranchEntry = widget.NewEntry()
+	retval.rulesEntry = widget.NewMultiLineEntry()
+	retval.rulesEntry.SetMinRowsVisible(6)
+	retval.requireLocksCheck = widget.NewCheck("Only commit lockable files I have locked", nil)
+	retval.unlockAfterPushCheck = widget.NewCheck("Unlock my unchanged files after pushing", nil)
+	retval.timeoutEntry = widget.NewEntry()
+	retval.longTimeoutEntry = widget.NewEntry()
+
+	form := widget.NewForm(
+		widget.NewFormItem("Git executable", retval.gitPathEntry),
+		widget.NewFormItem("Remote name", retval.remoteNameEntry),
+		widget.NewFormItem("Main branch", retval.mainBranchEntry),
+		widget.NewFormItem("Commit types", retval.rulesEntry),
+		widget.NewFormItem("Locks", container.NewVBox(retval.requireLocksCheck, retval.unlockAfterPushCheck)),
+		widget.NewFormItem("Command timeout", retval.timeoutEntry),
+		widget.NewFormItem("Long command timeout", retval.longTimeoutEntry),
+	)
+	form.Items[3].HintText = "One rule per line, \"Category: regex\". Files get every category they match."
+	form.Items[5].HintText = "Seconds before a git command is stopped, 0 for no limit."
+	form.Items[6].HintText = "Same, for fetch, pull, push, checkout and other commands that move a lot of data."
+
+	closeBtn := widget.NewButton("Close", nil)
+	saveBtn := widget.NewButton("Save", func() {
+		config, err := retval.GetConfig()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		retval.SaveCallback(config)
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	rect := canvas.NewRectangle(color.Transparent)
+	rect.SetMinSize(fyne.NewSize(600, 0))
+	border := container.NewBorder(nil, container.NewBorder(nil, nil, nil, container.NewHBox(closeBtn, saveBtn)), nil, nil, container.NewStack(rect, form))
+
+	dialog := dialog.NewCustomWithoutButtons("Project Settings", border, window)
+	closeBtn.OnTapped = func() {
+		dialog.Hide()
+	}
+
+	retval.CustomDialog = dialog
+
+	return retval
+}
@@ -0,0 +1,26 @@
+package view
+
+import (
+	"fyne.io/fyne/v2/widget"
+)
+
+// Small infinite progress bar shown while a section of the screen is loading
+type Spinner struct {
+	*widget.ProgressBarInfinite
+}
+
+func (this *Spinner) Start() {
+	this.Show()
+	this.ProgressBarInfinite.Start()
+}
+
+func (this *Spinner) Stop() {
+	this.ProgressBarInfinite.Stop()
+	this.Hide()
+}
+
+func MakeSpinner() *Spinner {
+	retval := &Spinner{ProgressBarInfinite: widget.NewProgressBarInfinite()}
+	retval.Stop()
+	return retval
+}
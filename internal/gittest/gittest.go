@@ -0,0 +1,207 @@
+// Package gittest builds throwaway repositories for end to end tests: a local bare repository
+// standing in for the server, an optional in-process LFS server, and working copies cloned from them.
+// Nothing touches the network or the git config of the machine running the tests.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+const MAIN_BRANCH = "main"
+
+type Remote struct {
+	t testing.TB
+
+	// The bare repository working copies push to and pull from
+	Path string
+	// nil unless the remote was made with NewLFSRemote
+	LFS *LFSServer
+
+	home string
+}
+
+// A bare repository with nothing in it. Skips the test when git is not installed.
+func NewRemote(t testing.TB) *Remote {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("end to end test, skipped with -short")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		skipOrFail(t, "git is not installed")
+	}
+
+	remote := &Remote{t: t, home: t.TempDir()}
+	isolateGitConfig(t, remote.home)
+
+	remote.Path = filepath.Join(t.TempDir(), "remote.git")
+	run(t, "", "git", "init", "--quiet", "--bare", "--initial-branch="+MAIN_BRANCH, remote.Path)
+	return remote
+}
+
+// Same as NewRemote with an LFS server for the large files and the locks. Skips the test when git-lfs is not installed.
+func NewLFSRemote(t testing.TB) *Remote {
+	t.Helper()
+	remote := NewRemote(t)
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		skipOrFail(t, "git-lfs is not installed")
+	}
+	// clean and smudge filters in the global config, clones need them before their first checkout
+	run(t, remote.home, "git", "lfs", "install", "--skip-repo")
+	remote.LFS = NewLFSServer(t)
+	return remote
+}
+
+// On CI a missing tool fails the test, a skip there would go unnoticed and the end to end tests would never run
+func skipOrFail(t testing.TB, reason string) {
+	t.Helper()
+	if os.Getenv("CI") != "" {
+		t.Fatal(reason + ", CI has to install it")
+	}
+	t.Skip(reason)
+}
+
+// Nothing from the machine running the tests leaks in: no global or system config, no credential prompts
+func isolateGitConfig(t testing.TB, home string) {
+	globalConfig := filepath.Join(home, ".gitconfig")
+	err := os.WriteFile(globalConfig, []byte("[init]\n\tdefaultBranch = "+MAIN_BRANCH+"\n[core]\n\tautocrlf = false\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", globalConfig)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_TERMINAL_PROMPT", "0")
+}
+
+// A working copy for user, cloned from the remote. user is the git user name and, with LFS, the lock owner.
+func (remote *Remote) Clone(user string) *WorkingCopy {
+	t := remote.t
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), user)
+	args := []string{"clone", "--quiet",
+		"-c", "user.name=" + user,
+		"-c", "user.email=" + user + "@example.com",
+	}
+	if remote.LFS != nil {
+		args = append(args, "-c", "lfs.url="+remote.LFS.URL(user))
+	}
+	args = append(args, remote.Path, path)
+	run(t, "", "git", args...)
+
+	wc := &WorkingCopy{t: t, User: user, Path: path}
+	if remote.LFS != nil {
+		// the pre-push hook that uploads the objects
+		wc.Git("lfs", "install", "--local")
+	}
+	wc.Repo = core.OpenRepo(path)
+	wc.Repo.Config.MainBranch = MAIN_BRANCH
+	// the tests never wait on a real server, something stuck is a bug
+	wc.Repo.Config.CommandTimeout = 60
+	wc.Repo.Config.LongCommandTimeout = 60
+	return wc
+}
+
+type WorkingCopy struct {
+	t testing.TB
+
+	User string
+	Path string
+	// Opened on Path, what the tests hand to core
+	Repo *core.Repo
+}
+
+// Runs git in the working copy and returns its trimmed output, failing the test if it fails
+func (wc *WorkingCopy) Git(args ...string) string {
+	wc.t.Helper()
+	return run(wc.t, wc.Path, "git", args...)
+}
+
+func (wc *WorkingCopy) WriteFile(path, content string) {
+	wc.t.Helper()
+	fullPath := filepath.Join(wc.Path, filepath.FromSlash(path))
+	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err != nil {
+		wc.t.Fatal(err)
+	}
+	err = os.WriteFile(fullPath, []byte(content), 0644)
+	if err != nil {
+		wc.t.Fatal(err)
+	}
+}
+
+func (wc *WorkingCopy) ReadFile(path string) string {
+	wc.t.Helper()
+	content, err := os.ReadFile(filepath.Join(wc.Path, filepath.FromSlash(path)))
+	if err != nil {
+		wc.t.Fatal(err)
+	}
+	return string(content)
+}
+
+// Writes the files (path to content), commits everything and returns the new HEAD
+func (wc *WorkingCopy) Commit(message string, files map[string]string) string {
+	wc.t.Helper()
+	for path, content := range files {
+		wc.WriteFile(path, content)
+	}
+	wc.Git("add", "--all")
+	wc.Git("commit", "--quiet", "--allow-empty", "-m", message)
+	return wc.Head()
+}
+
+// Marks pattern as an LFS file, and as lockable if asked, and commits the .gitattributes
+func (wc *WorkingCopy) TrackLFS(pattern string, lockable bool) {
+	wc.t.Helper()
+	args := []string{"lfs", "track"}
+	if lockable {
+		args = append(args, "--lockable")
+	}
+	wc.Git(append(args, pattern)...)
+	wc.Git("add", ".gitattributes")
+	wc.Git("commit", "--quiet", "-m", "Track "+pattern)
+}
+
+func (wc *WorkingCopy) Push() {
+	wc.t.Helper()
+	wc.Git("push", "--quiet", "--set-upstream", "origin", "HEAD:"+MAIN_BRANCH)
+}
+
+func (wc *WorkingCopy) Head() string {
+	wc.t.Helper()
+	return wc.Git("rev-parse", "HEAD")
+}
+
+// Every push from now on is refused by the server, like a protected branch would
+func (remote *Remote) RejectPushes() {
+	remote.t.Helper()
+	hook := filepath.Join(remote.Path, "hooks", "pre-receive")
+	err := os.WriteFile(hook, []byte("#!/bin/sh\necho \"pushes are closed\" >&2\nexit 1\n"), 0755)
+	if err != nil {
+		remote.t.Fatal(err)
+	}
+}
+
+// What the remote main branch points to
+func (remote *Remote) Head() string {
+	remote.t.Helper()
+	return run(remote.t, remote.Path, "git", "rev-parse", MAIN_BRANCH)
+}
+
+func run(t testing.TB, dir string, command string, args ...string) string {
+	t.Helper()
+	c := exec.Command(command, args...)
+	c.Dir = dir
+	out, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s: %v\n%s", command, strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
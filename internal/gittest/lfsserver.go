@@ -0,0 +1,267 @@
+package gittest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miltoncandelero/ugsg/core"
+)
+
+const LFS_CONTENT_TYPE = "application/vnd.git-lfs+json"
+
+// An in-process Git LFS server with the batch and the locks APIs, keeping everything in memory.
+// Each user gets its own endpoint (see URL) so the server knows who owns a lock without credentials.
+type LFSServer struct {
+	*httptest.Server
+
+	mutex      sync.Mutex
+	objects    map[string][]byte
+	locks      []core.LockDatum
+	nextLockID int
+}
+
+func NewLFSServer(t testing.TB) *LFSServer {
+	server := &LFSServer{objects: make(map[string][]byte)}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serve))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// The lfs.url for user
+func (server *LFSServer) URL(user string) string {
+	return server.Server.URL + "/" + user
+}
+
+// Locks path as user straight on the server, for locks held by someone without a working copy
+func (server *LFSServer) Lock(user, path string) core.LockDatum {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	lock, _ := server.createLock(user, path)
+	return lock
+}
+
+func (server *LFSServer) Locks() []core.LockDatum {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return slices.Clone(server.locks)
+}
+
+func (server *LFSServer) HasObject(oid string) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	_, found := server.objects[oid]
+	return found
+}
+
+func (server *LFSServer) ObjectCount() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return len(server.objects)
+}
+
+// /<user>/objects/batch, /<user>/storage/<oid>, /<user>/locks, /<user>/locks/verify and /<user>/locks/<id>/unlock
+func (server *LFSServer) serve(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+	user := parts[0]
+	route := parts[1:]
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	switch {
+	case len(route) == 2 && route[0] == "objects" && route[1] == "batch" && r.Method == http.MethodPost:
+		server.batch(w, r, user)
+	case len(route) == 2 && route[0] == "storage" && r.Method == http.MethodPut:
+		server.upload(w, r, route[1])
+	case len(route) == 2 && route[0] == "storage" && r.Method == http.MethodGet:
+		server.download(w, route[1])
+	case len(route) == 1 && route[0] == "locks" && r.Method == http.MethodGet:
+		server.listLocks(w, r)
+	case len(route) == 1 && route[0] == "locks" && r.Method == http.MethodPost:
+		server.lock(w, r, user)
+	case len(route) == 2 && route[0] == "locks" && route[1] == "verify" && r.Method == http.MethodPost:
+		server.verifyLocks(w, user)
+	case len(route) == 3 && route[0] == "locks" && route[2] == "unlock" && r.Method == http.MethodPost:
+		server.unlock(w, r, user, route[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", LFS_CONTENT_TYPE)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeLFSError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+type batchObject struct {
+	Oid     string                 `json:"oid"`
+	Size    int64                  `json:"size"`
+	Actions map[string]batchAction `json:"actions,omitempty"`
+	Error   *batchError            `json:"error,omitempty"`
+}
+
+type batchAction struct {
+	Href      string `json:"href"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+type batchError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (server *LFSServer) batch(w http.ResponseWriter, r *http.Request, user string) {
+	var request struct {
+		Operation string        `json:"operation"`
+		Objects   []batchObject `json:"objects"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeLFSError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	objects := make([]batchObject, 0, len(request.Objects))
+	for _, object := range request.Objects {
+		href := batchAction{Href: server.URL(user) + "/storage/" + object.Oid, ExpiresIn: 3600}
+		_, found := server.objects[object.Oid]
+		result := batchObject{Oid: object.Oid, Size: object.Size}
+		switch {
+		case request.Operation == "upload" && !found:
+			result.Actions = map[string]batchAction{"upload": href}
+		case request.Operation == "download" && found:
+			result.Actions = map[string]batchAction{"download": href}
+		case request.Operation == "download":
+			result.Error = &batchError{Code: http.StatusNotFound, Message: "Object does not exist"}
+		}
+		// uploading something the server already has needs no action
+		objects = append(objects, result)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"transfer": "basic", "objects": objects})
+}
+
+func (server *LFSServer) upload(w http.ResponseWriter, r *http.Request, oid string) {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeLFSError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	hash := sha256.Sum256(content)
+	if hex.EncodeToString(hash[:]) != oid {
+		writeLFSError(w, http.StatusUnprocessableEntity, "Content doesn't match the oid")
+		return
+	}
+	server.objects[oid] = content
+	w.WriteHeader(http.StatusOK)
+}
+
+func (server *LFSServer) download(w http.ResponseWriter, oid string) {
+	content, found := server.objects[oid]
+	if !found {
+		writeLFSError(w, http.StatusNotFound, "Object does not exist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(content)
+}
+
+func (server *LFSServer) createLock(user, path string) (core.LockDatum, bool) {
+	for _, lock := range server.locks {
+		if lock.Path == path {
+			return lock, false
+		}
+	}
+	server.nextLockID++
+	lock := core.LockDatum{
+		ID:       strconv.Itoa(server.nextLockID),
+		Path:     path,
+		LockedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	lock.Owner.Name = user
+	server.locks = append(server.locks, lock)
+	return lock, true
+}
+
+func (server *LFSServer) listLocks(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	id := r.URL.Query().Get("id")
+	locks := make([]core.LockDatum, 0)
+	for _, lock := range server.locks {
+		if (path == "" || lock.Path == path) && (id == "" || lock.ID == id) {
+			locks = append(locks, lock)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"locks": locks})
+}
+
+func (server *LFSServer) lock(w http.ResponseWriter, r *http.Request, user string) {
+	var request struct {
+		Path string `json:"path"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Path == "" {
+		writeLFSError(w, http.StatusBadRequest, "Missing the path to lock")
+		return
+	}
+
+	lock, created := server.createLock(user, request.Path)
+	if !created {
+		writeJSON(w, http.StatusConflict, map[string]any{"lock": lock, "message": "already created lock"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"lock": lock})
+}
+
+func (server *LFSServer) verifyLocks(w http.ResponseWriter, user string) {
+	ours := make([]core.LockDatum, 0)
+	theirs := make([]core.LockDatum, 0)
+	for _, lock := range server.locks {
+		if lock.Owner.Name == user {
+			ours = append(ours, lock)
+		} else {
+			theirs = append(theirs, lock)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ours": ours, "theirs": theirs})
+}
+
+func (server *LFSServer) unlock(w http.ResponseWriter, r *http.Request, user string, id string) {
+	var request struct {
+		Force bool `json:"force"`
+	}
+	// an empty body is an unlock without force
+	json.NewDecoder(r.Body).Decode(&request)
+
+	idx := slices.IndexFunc(server.locks, func(lock core.LockDatum) bool { return lock.ID == id })
+	if idx == -1 {
+		writeLFSError(w, http.StatusNotFound, "Lock not found")
+		return
+	}
+	lock := server.locks[idx]
+	if lock.Owner.Name != user && !request.Force {
+		writeLFSError(w, http.StatusForbidden, "Lock is owned by "+lock.Owner.Name)
+		return
+	}
+	server.locks = slices.Delete(server.locks, idx, idx+1)
+	writeJSON(w, http.StatusOK, map[string]any{"lock": lock})
+}
@@ -1,9 +1,17 @@
-package main
-
-import (
-	"github.com/miltoncandelero/ugsg/gui/controller"
-)
-
-func main() {
-	controller.InitializeApplication().Window.ShowAndRun()
-}
+package main
+
+import (
+	"os"
+
+	"github.com/miltoncandelero/ugsg/cli"
+	"github.com/miltoncandelero/ugsg/gui/controller"
+)
+
+func main() {
+	// A command means headless, no window gets created
+	if cli.IsCommandLine(os.Args[1:]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
+	controller.InitializeApplication().Window.ShowAndRun()
+}